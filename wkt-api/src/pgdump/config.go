@@ -0,0 +1,420 @@
+package main
+
+import (
+    "bytes"
+    "encoding/json"
+    "errors"
+    "flag"
+    "fmt"
+    "io/ioutil"
+    "net"
+    "net/url"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/BurntSushi/toml"
+    "github.com/lib/pq"
+    "gopkg.in/yaml.v2"
+)
+
+// Config is everything pgdump needs to know to start. Each setting is read,
+// in increasing order of precedence, from the defaults below, an optional
+// config file, environment variables and command line flags:
+//
+//   Setting               Flag                     Environment                   File key
+//   DB host               -db-host                 PGDUMP_DB_HOST                db.host
+//   DB port               -db-port                 PGDUMP_DB_PORT                db.port
+//   DB user               -db-user                 PGDUMP_DB_USER                db.user
+//   DB password           -db-password             PGDUMP_DB_PASSWORD            db.password
+//   DB name               -db-name                 PGDUMP_DB_NAME                db.name
+//   DB sslmode            -db-sslmode              PGDUMP_DB_SSLMODE             db.sslmode
+//   Max open connections  -db-max-open-conns       PGDUMP_DB_MAX_OPEN_CONNS      db.max_open_conns
+//   Max idle connections  -db-max-idle-conns       PGDUMP_DB_MAX_IDLE_CONNS      db.max_idle_conns
+//   Connection lifetime   -db-conn-max-lifetime    PGDUMP_DB_CONN_MAX_LIFETIME   db.conn_max_lifetime
+//   Listen address        -listen                  PGDUMP_LISTEN                 listen
+//   CORS origins          -cors-origins            PGDUMP_CORS_ORIGINS           cors_origins
+//   Request timeout       -request-timeout         PGDUMP_REQUEST_TIMEOUT        request_timeout
+//
+// The config file is named by -config or PGDUMP_CONFIG, and is read as YAML,
+// TOML or JSON according to its extension. CORS origins are comma separated
+// in flags and the environment, and a list in the file. Durations are
+// written like "30s" or "5m" everywhere.
+//
+// Only the tables listed under tables.<name> in the config file are
+// published, each as a layer of the same name; any other table name in a
+// request is refused.
+type Config struct {
+    DB             DBConfig               `yaml:"db" toml:"db" json:"db"`
+    Listen         string                 `yaml:"listen" toml:"listen" json:"listen"`
+    CORSOrigins    []string               `yaml:"cors_origins" toml:"cors_origins" json:"cors_origins"`
+    RequestTimeout Duration               `yaml:"request_timeout" toml:"request_timeout" json:"request_timeout"`
+    Tables         map[string]TableConfig `yaml:"tables" toml:"tables" json:"tables"`
+}
+
+type DBConfig struct {
+    Host     string `yaml:"host" toml:"host" json:"host"`
+    Port     int    `yaml:"port" toml:"port" json:"port"`
+    User     string `yaml:"user" toml:"user" json:"user"`
+    Password string `yaml:"password" toml:"password" json:"password"`
+    Name     string `yaml:"name" toml:"name" json:"name"`
+    SSLMode  string `yaml:"sslmode" toml:"sslmode" json:"sslmode"`
+
+    // Connection pool limits, see the matching sql.DB setters
+    MaxOpenConns    int      `yaml:"max_open_conns" toml:"max_open_conns" json:"max_open_conns"`
+    MaxIdleConns    int      `yaml:"max_idle_conns" toml:"max_idle_conns" json:"max_idle_conns"`
+    ConnMaxLifetime Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime" json:"conn_max_lifetime"`
+}
+
+// TableConfig says how to look up features in one published table: its
+// schema, the column that identifies a feature, that column's type (int,
+// bigint, text or uuid) and the geometry columns a client may ask for, the
+// first being the default. Anything left out is taken from defaultTable.
+type TableConfig struct {
+    Schema          string   `yaml:"schema" toml:"schema" json:"schema"`
+    Key             string   `yaml:"key" toml:"key" json:"key"`
+    KeyType         string   `yaml:"key_type" toml:"key_type" json:"key_type"`
+    GeometryColumns []string `yaml:"geometry_columns" toml:"geometry_columns" json:"geometry_columns"`
+}
+
+// defaultTable is the layout pgdump has always assumed.
+var defaultTable = TableConfig{Schema: "public", Key: "ID", KeyType: "int", GeometryColumns: []string{"geom"}}
+
+// table returns the TableConfig for the named table, with defaults filled
+// in, and false if the table is not published.
+func (config Config) table(name string) (TableConfig, bool) {
+    t, ok := config.Tables[name]
+    if !ok {
+        return t, false
+    }
+    if t.Schema == "" {
+        t.Schema = defaultTable.Schema
+    }
+    if t.Key == "" {
+        t.Key = defaultTable.Key
+    }
+    if t.KeyType == "" {
+        t.KeyType = defaultTable.KeyType
+    }
+    if len(t.GeometryColumns) == 0 {
+        t.GeometryColumns = defaultTable.GeometryColumns
+    }
+    return t, true
+}
+
+// from returns the quoted, schema qualified name of the named table for use
+// in a query.
+func (t TableConfig) from(name string) string {
+    return pq.QuoteIdentifier(t.Schema) + "." + pq.QuoteIdentifier(name)
+}
+
+// Duration is a time.Duration that config files write as a string such as
+// "30s", rather than a number of nanoseconds.
+type Duration struct {
+    time.Duration
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+    duration, err := time.ParseDuration(string(text))
+    if err != nil {
+        return err
+    }
+    d.Duration = duration
+    return nil
+}
+
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+    var text string
+    if err := unmarshal(&text); err != nil {
+        return err
+    }
+    return d.UnmarshalText([]byte(text))
+}
+
+func defaultConfig() Config {
+    return Config{
+        DB: DBConfig{
+            Host:            "localhost",
+            Port:            1337,
+            User:            "postgres",
+            Name:            "Lacuna",
+            SSLMode:         "disable",
+            MaxOpenConns:    20,
+            MaxIdleConns:    5,
+            ConnMaxLifetime: Duration{30 * time.Minute},
+        },
+        Listen:         ":8080",
+        CORSOrigins:    []string{"http://localhost"},
+        RequestTimeout: Duration{10 * time.Second},
+    }
+}
+
+// A setting is one value that can be given as a flag or an environment
+// variable. set parses the text of either into config.
+type setting struct {
+    flag  string
+    env   string
+    usage string
+    set   func(config *Config, value string) error
+}
+
+var settings = []setting{
+    {"db-host", "PGDUMP_DB_HOST", "database host", func(c *Config, v string) error {
+        c.DB.Host = v
+        return nil
+    }},
+    {"db-port", "PGDUMP_DB_PORT", "database port", func(c *Config, v string) error {
+        return setInt(&c.DB.Port, v)
+    }},
+    {"db-user", "PGDUMP_DB_USER", "database user", func(c *Config, v string) error {
+        c.DB.User = v
+        return nil
+    }},
+    {"db-password", "PGDUMP_DB_PASSWORD", "database password, better kept out of the command line", func(c *Config, v string) error {
+        c.DB.Password = v
+        return nil
+    }},
+    {"db-name", "PGDUMP_DB_NAME", "database name", func(c *Config, v string) error {
+        c.DB.Name = v
+        return nil
+    }},
+    {"db-sslmode", "PGDUMP_DB_SSLMODE", "database sslmode", func(c *Config, v string) error {
+        c.DB.SSLMode = v
+        return nil
+    }},
+    {"db-max-open-conns", "PGDUMP_DB_MAX_OPEN_CONNS", "most open database connections", func(c *Config, v string) error {
+        return setInt(&c.DB.MaxOpenConns, v)
+    }},
+    {"db-max-idle-conns", "PGDUMP_DB_MAX_IDLE_CONNS", "most idle database connections kept open", func(c *Config, v string) error {
+        return setInt(&c.DB.MaxIdleConns, v)
+    }},
+    {"db-conn-max-lifetime", "PGDUMP_DB_CONN_MAX_LIFETIME", "longest a database connection is reused, e.g. 30m", func(c *Config, v string) error {
+        return c.DB.ConnMaxLifetime.UnmarshalText([]byte(v))
+    }},
+    {"listen", "PGDUMP_LISTEN", "address to listen on", func(c *Config, v string) error {
+        c.Listen = v
+        return nil
+    }},
+    {"cors-origins", "PGDUMP_CORS_ORIGINS", "comma separated allowed CORS origins", func(c *Config, v string) error {
+        c.CORSOrigins = splitList(v)
+        return nil
+    }},
+    {"request-timeout", "PGDUMP_REQUEST_TIMEOUT", "longest a request may take, e.g. 10s", func(c *Config, v string) error {
+        return c.RequestTimeout.UnmarshalText([]byte(v))
+    }},
+}
+
+func setInt(dst *int, value string) error {
+    n, err := strconv.Atoi(value)
+    if err != nil {
+        return fmt.Errorf("%q is not a whole number", value)
+    }
+    *dst = n
+    return nil
+}
+
+// loadConfig builds the Config from the command line arguments (without the
+// program name) and the environment, then validates it.
+func loadConfig(args []string, getenv func(string) string) (Config, error) {
+
+    config := defaultConfig()
+
+    fs := flag.NewFlagSet("pgdump", flag.ContinueOnError)
+    path := fs.String("config", "", "YAML, TOML or JSON config file (env PGDUMP_CONFIG)")
+    flags := make(map[string]*string)
+    for _, s := range settings {
+        flags[s.flag] = fs.String(s.flag, "", fmt.Sprintf("%s (env %s)", s.usage, s.env))
+    }
+    if err := fs.Parse(args); err != nil {
+        return config, err
+    }
+    if fs.NArg() > 0 {
+        return config, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+    }
+
+    // Config file
+    if *path == "" {
+        *path = getenv("PGDUMP_CONFIG")
+    }
+    if *path != "" {
+        if err := config.loadFile(*path); err != nil {
+            return config, err
+        }
+    }
+
+    // Environment
+    for _, s := range settings {
+        if v := getenv(s.env); v != "" {
+            if err := s.set(&config, v); err != nil {
+                return config, fmt.Errorf("%s: %v", s.env, err)
+            }
+        }
+    }
+
+    // Flags, only those actually given so their empty defaults don't count
+    var err error
+    fs.Visit(func(f *flag.Flag) {
+        for _, s := range settings {
+            if s.flag == f.Name && err == nil {
+                if e := s.set(&config, *flags[s.flag]); e != nil {
+                    err = fmt.Errorf("-%s: %v", s.flag, e)
+                }
+            }
+        }
+    })
+    if err != nil {
+        return config, err
+    }
+
+    return config, config.validate()
+}
+
+// loadFile overlays the settings in a config file on config. Unknown keys
+// are an error, so a misspelt setting is not silently ignored.
+func (config *Config) loadFile(path string) error {
+
+    data, err := ioutil.ReadFile(path)
+    if err != nil {
+        return err
+    }
+
+    switch strings.ToLower(filepath.Ext(path)) {
+    case ".yaml", ".yml":
+        err = yaml.UnmarshalStrict(data, config)
+    case ".toml":
+        var meta toml.MetaData
+        meta, err = toml.Decode(string(data), config)
+        if err == nil && len(meta.Undecoded()) > 0 {
+            err = fmt.Errorf("unknown key %q", meta.Undecoded()[0].String())
+        }
+    case ".json":
+        decoder := json.NewDecoder(bytes.NewReader(data))
+        decoder.DisallowUnknownFields()
+        err = decoder.Decode(config)
+    default:
+        err = errors.New("config file must end in .yaml, .yml, .toml or .json")
+    }
+
+    if err != nil {
+        return fmt.Errorf("%s: %v", path, err)
+    }
+    return nil
+}
+
+// validate reports the first setting that pgdump cannot start with.
+func (config Config) validate() error {
+
+    db := config.DB
+    switch {
+    case db.Host == "":
+        return errors.New("database host is not set")
+    case db.Port < 1 || db.Port > 65535:
+        return fmt.Errorf("database port %d is not between 1 and 65535", db.Port)
+    case db.User == "":
+        return errors.New("database user is not set")
+    case db.Name == "":
+        return errors.New("database name is not set")
+    case db.MaxOpenConns < 1:
+        return fmt.Errorf("max open connections %d is less than 1", db.MaxOpenConns)
+    case db.MaxIdleConns < 0 || db.MaxIdleConns > db.MaxOpenConns:
+        return fmt.Errorf("max idle connections %d is not between 0 and max open connections", db.MaxIdleConns)
+    case db.ConnMaxLifetime.Duration < 0:
+        return errors.New("connection lifetime is negative")
+    case config.RequestTimeout.Duration <= 0:
+        return errors.New("request timeout must be more than 0")
+    }
+
+    switch db.SSLMode {
+    case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+    default:
+        return fmt.Errorf("database sslmode %q is not one of disable, allow, prefer, require, verify-ca or verify-full", db.SSLMode)
+    }
+
+    _, port, err := net.SplitHostPort(config.Listen)
+    if err != nil {
+        return fmt.Errorf("listen address %q: %v", config.Listen, err)
+    }
+    if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+        return fmt.Errorf("listen address %q has an invalid port", config.Listen)
+    }
+
+    if len(config.Tables) == 0 {
+        return errors.New("no tables are published, list them under tables in the config file")
+    }
+    for name := range config.Tables {
+        t, _ := config.table(name)
+        switch t.KeyType {
+        case "int", "bigint", "text", "uuid":
+        default:
+            return fmt.Errorf("table %q: key type %q is not one of int, bigint, text or uuid", name, t.KeyType)
+        }
+        for i, column := range t.GeometryColumns {
+            if column == "" {
+                return fmt.Errorf("table %q: geometry column %d has no name", name, i+1)
+            }
+            for _, other := range t.GeometryColumns[:i] {
+                if column == other {
+                    return fmt.Errorf("table %q: geometry column %q is listed twice", name, column)
+                }
+            }
+        }
+    }
+
+    if len(config.CORSOrigins) == 0 {
+        return errors.New("no CORS origins are set")
+    }
+    for _, origin := range config.CORSOrigins {
+        if origin == "*" {
+            continue
+        }
+        u, err := url.Parse(origin)
+        if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+            u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+            return fmt.Errorf("CORS origin %q must be * or a scheme and host such as https://example.com", origin)
+        }
+    }
+
+    return nil
+}
+
+// DataSourceName returns the lib/pq connection string for the database,
+// quoting values so a password may contain spaces or quotes. An empty
+// password is left out, so lib/pq can fall back to PGPASSWORD, .pgpass or a
+// server that does not ask for one (trust or peer authentication).
+func (db DBConfig) DataSourceName() string {
+    quote := func(s string) string {
+        return "'" + strings.Replace(strings.Replace(s, `\`, `\\`, -1), `'`, `\'`, -1) + "'"
+    }
+    password := ""
+    if db.Password != "" {
+        password = " password=" + quote(db.Password)
+    }
+    return fmt.Sprintf("host=%s port=%d user=%s%s dbname=%s sslmode=%s",
+                       quote(db.Host), db.Port, quote(db.User), password, quote(db.Name), db.SSLMode)
+}
+
+// splitList splits a comma separated list, dropping blank entries.
+func splitList(s string) []string {
+    var list []string
+    for _, item := range strings.Split(s, ",") {
+        if item = strings.TrimSpace(item); item != "" {
+            list = append(list, item)
+        }
+    }
+    return list
+}
+
+// mustLoadConfig is loadConfig for main, which cannot start without one.
+func mustLoadConfig() Config {
+    config, err := loadConfig(os.Args[1:], os.Getenv)
+    if err == flag.ErrHelp {
+        os.Exit(0)
+    }
+    if err != nil {
+        fmt.Fprintln(os.Stderr, "pgdump:", err)
+        os.Exit(2)
+    }
+    return config
+}
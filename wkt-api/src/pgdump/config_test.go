@@ -0,0 +1,230 @@
+package main
+
+import (
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "reflect"
+    "strings"
+    "testing"
+    "time"
+)
+
+// validConfig is the defaults with one published table, which is enough to
+// pass validate.
+func validConfig() Config {
+    config := defaultConfig()
+    config.Tables = map[string]TableConfig{"roofs": {}}
+    return config
+}
+
+// writeConfigFile writes a config file into a new temporary directory and
+// returns its path.
+func writeConfigFile(t *testing.T, name string, content string) string {
+    dir, err := ioutil.TempDir("", "pgdump")
+    if err != nil {
+        t.Fatal(err)
+    }
+    path := filepath.Join(dir, name)
+    if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+        os.RemoveAll(dir)
+        t.Fatal(err)
+    }
+    return path
+}
+
+// environment returns a getenv for loadConfig that only sees env.
+func environment(env map[string]string) func(string) string {
+    return func(key string) string {
+        return env[key]
+    }
+}
+
+func TestTableDefaults(t *testing.T) {
+
+    config := Config{Tables: map[string]TableConfig{
+        "roofs":     {},
+        "buildings": {Schema: "city", Key: "gid", KeyType: "bigint", GeometryColumns: []string{"footprint", "geom"}},
+        "parcels":   {KeyType: "uuid"},
+    }}
+
+    tests := []struct {
+        name string
+        want TableConfig
+    }{
+        {"roofs", defaultTable},
+        {"buildings", TableConfig{Schema: "city", Key: "gid", KeyType: "bigint", GeometryColumns: []string{"footprint", "geom"}}},
+        {"parcels", TableConfig{Schema: "public", Key: "ID", KeyType: "uuid", GeometryColumns: []string{"geom"}}},
+    }
+
+    for _, test := range tests {
+        got, ok := config.table(test.name)
+        if !ok {
+            t.Error("Expected ", test.name, " to be published")
+        } else if !reflect.DeepEqual(got, test.want) {
+            t.Error(test.name, ": expected ", test.want, " got ", got)
+        }
+    }
+
+    if _, ok := config.table("secrets"); ok {
+        t.Error("Expected an unlisted table not to be published")
+    }
+
+    if from := tests[1].want.from("buildings"); from != `"city"."buildings"` {
+        t.Error(`Expected "city"."buildings" got `, from)
+    }
+
+}
+
+func TestValidate(t *testing.T) {
+
+    tests := []struct {
+        change func(config *Config)
+        err    string // Part of the error, or "" if the config is valid
+    }{
+        {func(c *Config) {}, ""},
+        {func(c *Config) { c.DB.Password = "" }, ""},
+        {func(c *Config) { c.Listen = "127.0.0.1:0" }, ""},
+        {func(c *Config) { c.CORSOrigins = []string{"*"} }, ""},
+        {func(c *Config) { c.CORSOrigins = []string{"https://example.com", "http://localhost:3000"} }, ""},
+        {func(c *Config) { c.DB.Host = "" }, "database host"},
+        {func(c *Config) { c.DB.Port = 0 }, "database port"},
+        {func(c *Config) { c.DB.Port = 65536 }, "database port"},
+        {func(c *Config) { c.DB.User = "" }, "database user"},
+        {func(c *Config) { c.DB.Name = "" }, "database name"},
+        {func(c *Config) { c.DB.SSLMode = "sometimes" }, "sslmode"},
+        {func(c *Config) { c.DB.MaxOpenConns = 0 }, "max open connections"},
+        {func(c *Config) { c.DB.MaxIdleConns = 21 }, "max idle connections"},
+        {func(c *Config) { c.DB.MaxIdleConns = -1 }, "max idle connections"},
+        {func(c *Config) { c.DB.ConnMaxLifetime.Duration = -time.Second }, "connection lifetime"},
+        {func(c *Config) { c.RequestTimeout.Duration = 0 }, "request timeout"},
+        {func(c *Config) { c.Tables["roofs"] = TableConfig{Key: "gid", KeyType: "uuid"} }, ""},
+        {func(c *Config) { c.Tables = nil }, "no tables"},
+        {func(c *Config) { c.Tables["roofs"] = TableConfig{KeyType: "float"} }, "key type"},
+        {func(c *Config) { c.Tables["roofs"] = TableConfig{GeometryColumns: []string{"geom", ""}} }, "has no name"},
+        {func(c *Config) { c.Tables["roofs"] = TableConfig{GeometryColumns: []string{"geom", "geom"}} }, "listed twice"},
+        {func(c *Config) { c.Listen = "8080" }, "listen address"},
+        {func(c *Config) { c.Listen = ":http-alt" }, "invalid port"},
+        {func(c *Config) { c.CORSOrigins = nil }, "no CORS origins"},
+        {func(c *Config) { c.CORSOrigins = []string{"example.com"} }, "CORS origin"},
+        {func(c *Config) { c.CORSOrigins = []string{"https://example.com/app"} }, "CORS origin"},
+    }
+
+    for i, test := range tests {
+
+        config := validConfig()
+        test.change(&config)
+        err := config.validate()
+
+        if test.err == "" {
+            if err != nil {
+                t.Error("Case ", i, ": expected no error got ", err)
+            }
+        } else if err == nil || !strings.Contains(err.Error(), test.err) {
+            t.Error("Case ", i, ": expected an error about ", test.err, " got ", err)
+        }
+    }
+
+}
+
+func TestDataSourceName(t *testing.T) {
+
+    db := defaultConfig().DB
+    if dsn := db.DataSourceName(); strings.Contains(dsn, "password") {
+        t.Error("Expected no password in ", dsn)
+    }
+
+    db.Password = `it's a \secret`
+    want := `host='localhost' port=1337 user='postgres' password='it\'s a \\secret' dbname='Lacuna' sslmode=disable`
+    if dsn := db.DataSourceName(); dsn != want {
+        t.Error("Expected ", want, " got ", dsn)
+    }
+
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+
+    path := writeConfigFile(t, "pgdump.yaml", `
+db:
+  host: filehost
+  user: fileuser
+listen: ":7000"
+tables:
+  roofs: {}
+`)
+    defer os.RemoveAll(filepath.Dir(path))
+
+    tests := []struct {
+        args   []string
+        env    map[string]string
+        host   string
+        user   string
+        listen string
+    }{
+        // The file overrides the defaults
+        {[]string{"-config", path}, nil, "filehost", "fileuser", ":7000"},
+        // The environment overrides the file, and can name it
+        {nil, map[string]string{"PGDUMP_CONFIG": path, "PGDUMP_LISTEN": ":8000", "PGDUMP_DB_HOST": "envhost"}, "envhost", "fileuser", ":8000"},
+        // Flags override the environment
+        {[]string{"-config", path, "-listen", ":9000"}, map[string]string{"PGDUMP_LISTEN": ":8000", "PGDUMP_DB_HOST": "envhost"}, "envhost", "fileuser", ":9000"},
+        {[]string{"-config", path, "-db-user", "flaguser"}, map[string]string{"PGDUMP_DB_USER": "envuser"}, "filehost", "flaguser", ":7000"},
+    }
+
+    for i, test := range tests {
+
+        config, err := loadConfig(test.args, environment(test.env))
+        if err != nil {
+            t.Error("Case ", i, ": ", err)
+            continue
+        }
+
+        if config.DB.Host != test.host || config.DB.User != test.user || config.Listen != test.listen {
+            t.Error("Case ", i, ": expected ", test.host, " ", test.user, " ", test.listen,
+                    " got ", config.DB.Host, " ", config.DB.User, " ", config.Listen)
+        }
+
+        // Anything set nowhere keeps its default
+        if config.DB.Port != 1337 || config.DB.Name != "Lacuna" {
+            t.Error("Case ", i, ": expected the default port and name got ", config.DB.Port, " ", config.DB.Name)
+        }
+    }
+
+}
+
+func TestLoadConfigFile(t *testing.T) {
+
+    tests := []struct {
+        name    string
+        content string
+        err     string // Part of the error, or "" if the file is valid
+    }{
+        {"pgdump.yaml", "listen: \":7000\"\nrequest_timeout: 5s\ndb:\n  port: 5432\ntables:\n  roofs: {}\n", ""},
+        {"pgdump.toml", "listen = \":7000\"\nrequest_timeout = \"5s\"\n[db]\nport = 5432\n[tables.roofs]\n", ""},
+        {"pgdump.json", `{"listen": ":7000", "request_timeout": "5s", "db": {"port": 5432}, "tables": {"roofs": {}}}`, ""},
+        {"pgdump.yaml", "listen: \":7000\"\nlisten_address: \":7000\"\ntables:\n  roofs: {}\n", "listen_address"},
+        {"pgdump.toml", "listn = \":7000\"\n[tables.roofs]\n", "listn"},
+        {"pgdump.json", `{"db": {"hostname": "db"}, "tables": {"roofs": {}}}`, "hostname"},
+        {"pgdump.json", `{"tables": {"roofs": {"keytype": "int"}}}`, "keytype"},
+        {"pgdump.ini", "listen = :7000\n", "must end in"},
+        {"pgdump.yaml", "db:\n  port: high\ntables:\n  roofs: {}\n", "high"},
+        {"pgdump.yaml", "request_timeout: soon\ntables:\n  roofs: {}\n", "soon"},
+    }
+
+    for _, test := range tests {
+
+        path := writeConfigFile(t, test.name, test.content)
+        config, err := loadConfig([]string{"-config", path}, environment(nil))
+        os.RemoveAll(filepath.Dir(path))
+
+        if test.err == "" {
+            if err != nil {
+                t.Error(test.name, ": ", err)
+            } else if config.Listen != ":7000" || config.DB.Port != 5432 || config.RequestTimeout.Duration != 5*time.Second {
+                t.Error(test.name, ": expected :7000, port 5432 and 5s got ", config.Listen, " ", config.DB.Port, " ", config.RequestTimeout.Duration)
+            }
+        } else if err == nil || !strings.Contains(err.Error(), test.err) {
+            t.Error(test.name, ": expected an error about ", test.err, " got ", err)
+        }
+    }
+
+}
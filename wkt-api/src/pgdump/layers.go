@@ -0,0 +1,162 @@
+package main
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "fmt"
+    "log"
+    "net/http"
+    "sort"
+    "time"
+
+    "github.com/lib/pq"
+)
+
+// Layer describes one geometry column of a published table. The type, SRID
+// and dimension are what PostGIS records in its geometry_columns view.
+type Layer struct {
+    Name           string
+    GeometryColumn string
+    Default        bool      // Returned when a request does not ask for a geometry column
+    GeometryType   string    // e.g. MULTIPOLYGON, or GEOMETRY if the column takes any type
+    SRID           int
+    Dimension      int
+    Rows           *int64    // Estimated, or null if the table has never been analyzed
+    Extent         []float64 // Estimated [minx, miny, maxx, maxy], or null if there are no statistics yet
+}
+
+type LayersJSON struct {
+    Layers   []Layer
+    Elapsed  time.Duration
+}
+
+// catalog returns every published layer, in name order, with what
+// geometry_columns says about it and its estimated row count. It is an
+// error for a layer to be missing from geometry_columns, as then the table
+// or column does not exist or does not hold geometries.
+//
+// Every layer is looked up in the one query, so /layers costs one round
+// trip however many tables are published. Counting rows exactly would mean
+// scanning each table on every request, so the count is the planner's
+// estimate, pg_class.reltuples, which lags behind recent writes and is null
+// until ANALYZE (or autovacuum) has looked at the table.
+func (s *server) catalog(ctx context.Context) ([]Layer, error) {
+
+    names := make([]string, 0, len(s.config.Tables))
+    for name := range s.config.Tables {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    var schemas, tables, columns []string
+    for _, name := range names {
+        config, _ := s.config.table(name)
+        for _, column := range config.GeometryColumns {
+            schemas = append(schemas, config.Schema)
+            tables = append(tables, name)
+            columns = append(columns, column)
+        }
+    }
+
+    rows, err := s.db.QueryContext(ctx,
+        "SELECT g.f_table_schema, g.f_table_name, g.f_geometry_column, g.type, g.srid, g.coord_dimension, " +
+        "CASE WHEN c.reltuples < 0 THEN NULL ELSE c.reltuples::bigint END " +
+        "FROM geometry_columns g " +
+        "JOIN unnest($1::text[], $2::text[], $3::text[]) AS l (table_schema, table_name, column_name) " +
+        "ON g.f_table_schema = l.table_schema AND g.f_table_name = l.table_name AND g.f_geometry_column = l.column_name " +
+        "LEFT JOIN pg_namespace n ON n.nspname = g.f_table_schema " +
+        "LEFT JOIN pg_class c ON c.relnamespace = n.oid AND c.relname = g.f_table_name",
+        pq.Array(schemas), pq.Array(tables), pq.Array(columns))
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    found := map[[3]string]Layer{}
+    for rows.Next() {
+        var (
+            key   [3]string
+            layer Layer
+            count sql.NullInt64
+        )
+        if err := rows.Scan(&key[0], &key[1], &key[2], &layer.GeometryType, &layer.SRID, &layer.Dimension, &count); err != nil {
+            return nil, err
+        }
+        if count.Valid {
+            layer.Rows = &count.Int64
+        }
+        found[key] = layer
+    }
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+
+    layers := []Layer{}
+    for i := range tables {
+        layer, ok := found[[3]string{schemas[i], tables[i], columns[i]}]
+        if !ok {
+            return nil, fmt.Errorf("layer %q: %s.%s.%s is not in geometry_columns", tables[i], schemas[i], tables[i], columns[i])
+        }
+        config, _ := s.config.table(tables[i])
+        layer.Name = tables[i]
+        layer.GeometryColumn = columns[i]
+        layer.Default = columns[i] == config.GeometryColumns[0]
+        layers = append(layers, layer)
+    }
+
+    return layers, nil
+}
+
+// extent fills in the estimated extent of a layer from ST_EstimatedExtent,
+// which reads the statistics ANALYZE keeps rather than scanning the table.
+// Some PostGIS versions raise an error rather than return null for a table
+// that has never been analyzed, so a database error only leaves the extent
+// null, and is logged, rather than failing the whole catalog.
+func (s *server) extent(ctx context.Context, layer *Layer) error {
+
+    config, _ := s.config.table(layer.Name)
+
+    var extent [4]sql.NullFloat64
+    err := s.db.QueryRowContext(ctx,
+        "SELECT ST_XMin(e), ST_YMin(e), ST_XMax(e), ST_YMax(e) FROM ST_EstimatedExtent($1, $2, $3) AS e",
+        config.Schema, layer.Name, layer.GeometryColumn).Scan(&extent[0], &extent[1], &extent[2], &extent[3])
+    if _, ok := err.(*pq.Error); ok && ctx.Err() == nil {
+        log.Printf("pgdump: no estimated extent for layer %q column %q: %v", layer.Name, layer.GeometryColumn, err)
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+
+    if extent[0].Valid {
+        layer.Extent = []float64{extent[0].Float64, extent[1].Float64, extent[2].Float64, extent[3].Float64}
+    }
+
+    return nil
+}
+
+func (s *server) layersHandler(w http.ResponseWriter, r *http.Request) {
+
+    jsonenc := json.NewEncoder(w)
+    w.Header().Set("Content-Type", "application/json")
+
+    start := time.Now()
+
+    ctx, cancel := context.WithTimeout(r.Context(), s.config.RequestTimeout.Duration)
+    defer cancel()
+
+    layers, err := s.catalog(ctx)
+    if err != nil {
+        handleQueryError(ctx, w, err)
+        return
+    }
+    for i := range layers {
+        if err := s.extent(ctx, &layers[i]); err != nil {
+            handleQueryError(ctx, w, err)
+            return
+        }
+    }
+
+    jsonenc.Encode(LayersJSON{Layers: layers, Elapsed: time.Since(start) / time.Millisecond})
+}
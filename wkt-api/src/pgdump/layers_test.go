@@ -0,0 +1,148 @@
+package main
+
+import (
+    "context"
+    "database/sql/driver"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "reflect"
+    "strings"
+    "sync"
+    "testing"
+
+    "github.com/lib/pq"
+)
+
+// fakeCatalog answers the catalog query with rows, counting how often it is
+// asked, and each extent query with extents, keyed by table and column. A
+// layer with no extent gets the error PostGIS raises for a table that has
+// never been analyzed.
+type fakeCatalog struct {
+    mu      sync.Mutex
+    queries int
+    rows    [][]driver.Value
+    extents map[string][]driver.Value
+}
+
+func (c *fakeCatalog) query(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+
+    if strings.Contains(query, "geometry_columns") {
+        c.mu.Lock()
+        c.queries++
+        c.mu.Unlock()
+        return &fakeRows{
+            columns: []string{"f_table_schema", "f_table_name", "f_geometry_column", "type", "srid", "coord_dimension", "reltuples"},
+            values:  append([][]driver.Value(nil), c.rows...),
+        }, nil
+    }
+
+    if strings.Contains(query, "ST_EstimatedExtent") && len(args) == 3 {
+        extent, ok := c.extents[args[1].Value.(string)+"."+args[2].Value.(string)]
+        if !ok {
+            return nil, &pq.Error{Code: "XX000", Message: "stats for \"" + args[1].Value.(string) + "." + args[2].Value.(string) + "\" do not exist"}
+        }
+        return &fakeRows{
+            columns: []string{"st_xmin", "st_ymin", "st_xmax", "st_ymax"},
+            values:  [][]driver.Value{extent},
+        }, nil
+    }
+
+    return nil, errors.New("unexpected query " + query)
+}
+
+// newLayersServer returns a server publishing roofs and buildings over
+// catalog.
+func newLayersServer(t *testing.T, catalog *fakeCatalog) *server {
+    s := newTestServer(t, catalog.query)
+    s.config.Tables = map[string]TableConfig{
+        "roofs":     {},
+        "buildings": {Schema: "city", GeometryColumns: []string{"footprint", "geom"}},
+    }
+    return s
+}
+
+func TestLayers(t *testing.T) {
+
+    catalog := &fakeCatalog{
+        rows: [][]driver.Value{
+            {"public", "roofs", "geom", "MULTIPOLYGON", int64(27700), int64(3), int64(120)},
+            {"city", "buildings", "geom", "POINT", int64(4326), int64(2), nil},
+            {"city", "buildings", "footprint", "POLYGON", int64(4326), int64(2), nil},
+        },
+        extents: map[string][]driver.Value{
+            "roofs.geom":     {float64(1), float64(2), float64(3), float64(4)},
+            "buildings.geom": {nil, nil, nil, nil},
+        },
+    }
+    s := newLayersServer(t, catalog)
+    defer s.Close()
+
+    w := httptest.NewRecorder()
+    s.layersHandler(w, httptest.NewRequest("GET", "/layers", nil))
+    if w.Code != http.StatusOK {
+        t.Fatal("Expected ", http.StatusOK, " got ", w.Code, " ", w.Body.String())
+    }
+
+    var got LayersJSON
+    if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+        t.Fatal(err)
+    }
+
+    rows := int64(120)
+    want := []Layer{
+        // An extent PostGIS cannot estimate is null, not a failed request
+        {Name: "buildings", GeometryColumn: "footprint", Default: true, GeometryType: "POLYGON", SRID: 4326, Dimension: 2},
+        {Name: "buildings", GeometryColumn: "geom", GeometryType: "POINT", SRID: 4326, Dimension: 2},
+        {Name: "roofs", GeometryColumn: "geom", Default: true, GeometryType: "MULTIPOLYGON", SRID: 27700, Dimension: 3, Rows: &rows, Extent: []float64{1, 2, 3, 4}},
+    }
+    if !reflect.DeepEqual(got.Layers, want) {
+        t.Error("Expected ", want, " got ", got.Layers)
+    }
+
+    if catalog.queries != 1 {
+        t.Error("Expected one catalog query for every layer got ", catalog.queries)
+    }
+
+}
+
+func TestLayersMissing(t *testing.T) {
+
+    catalog := &fakeCatalog{
+        rows: [][]driver.Value{
+            {"public", "roofs", "geom", "MULTIPOLYGON", int64(27700), int64(3), int64(120)},
+            {"city", "buildings", "geom", "POINT", int64(4326), int64(2), nil},
+        },
+    }
+    s := newLayersServer(t, catalog)
+    defer s.Close()
+
+    _, err := s.catalog(context.Background())
+    if err == nil || !strings.Contains(err.Error(), "city.buildings.footprint is not in geometry_columns") {
+        t.Error("Expected an error about city.buildings.footprint got ", err)
+    }
+
+    w := httptest.NewRecorder()
+    s.layersHandler(w, httptest.NewRequest("GET", "/layers", nil))
+    if w.Code != http.StatusInternalServerError {
+        t.Error("Expected ", http.StatusInternalServerError, " got ", w.Code, " ", w.Body.String())
+    }
+
+}
+
+func TestLayersTimeout(t *testing.T) {
+
+    s := newTestServer(t, func(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+        <-ctx.Done()
+        return nil, ctx.Err()
+    })
+    defer s.Close()
+
+    w := httptest.NewRecorder()
+    s.layersHandler(w, httptest.NewRequest("GET", "/layers", nil))
+    if w.Code != http.StatusGatewayTimeout {
+        t.Error("Expected ", http.StatusGatewayTimeout, " got ", w.Code, " ", w.Body.String())
+    }
+
+}
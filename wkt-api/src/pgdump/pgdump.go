@@ -2,213 +2,333 @@ package main
 
 import (
     "fmt"
+    "context"
     "encoding/json"
     "os"
+    "os/signal"
+    "syscall"
     "io"
     "log"
     "net/http"
     "database/sql"
-    "strings"
     "time"
-    "strconv"
+    "errors"
     "regexp"
+    "strconv"
+    "strings"
+    "wktparse"
     "github.com/lib/pq"
     "github.com/rs/cors"
 )
 
+// Coordinate, CoordinateSet and WTK keep the response shape pgdump has
+// always had, which existing clients read, rather than exposing wktparse's
+// richer types. The shape is lossy on purpose: every point has exactly X, Y
+// and Z, so Z is 0 for 2D data and M values are dropped. Use format=geojson,
+// or wktparse directly, where M matters.
 type Coordinate struct {
     X   float64
     Y   float64
     Z   float64
 }
 
+// CoordinateSet is one ring, line or part of a geometry as a flat list.
 type CoordinateSet struct {
     Coordinates []Coordinate  //(0 0 0, 0 1 0, 1 1 0, 1 0 0, 0 0 0)
 }
 
-// Define WTK
+// WTK is one geometry, or one member of a GEOMETRYCOLLECTION: its WKT type,
+// such as "POLYGON Z", and a CoordinateSet for each of its rings or parts.
 type WTK struct {
     WTKType     string
     Geometry    []CoordinateSet
 }
 
+// ReturnJSON is the default response. WTKGeoms holds one entry per member
+// of a GEOMETRYCOLLECTION, or just the one for any other geometry, and
+// Elapsed is in milliseconds.
 type ReturnJSON struct {
     WTKGeoms  []WTK
     Elapsed   time.Duration
 }
 
+// How long in-flight requests get to finish once a shutdown signal arrives.
+const shutdownTimeout = 30 * time.Second
+
+// server owns the database connection pool, which every request shares.
+type server struct {
+    config Config
+    db     *sql.DB
+}
+
+// newServer opens the pool and checks the database can be reached and has
+// every published layer, so a bad password, a missing database or a
+// misspelt table stops pgdump at startup rather than failing requests.
+func newServer(config Config) (*server, error) {
+
+    db, err := sql.Open("postgres", config.DB.DataSourceName())
+    if err != nil {
+        return nil, err
+    }
+    db.SetMaxOpenConns(config.DB.MaxOpenConns)
+    db.SetMaxIdleConns(config.DB.MaxIdleConns)
+    db.SetConnMaxLifetime(config.DB.ConnMaxLifetime.Duration)
+
+    ctx, cancel := context.WithTimeout(context.Background(), config.RequestTimeout.Duration)
+    defer cancel()
+    if err := db.PingContext(ctx); err != nil {
+        db.Close()
+        return nil, fmt.Errorf("cannot connect to database %q on %s:%d: %v", config.DB.Name, config.DB.Host, config.DB.Port, err)
+    }
+
+    // Every published layer must exist before any request asks for it.
+    s := &server{config: config, db: db}
+    if _, err := s.catalog(ctx); err != nil {
+        db.Close()
+        return nil, err
+    }
+
+    return s, nil
+}
+
+func (s *server) Close() error {
+    return s.db.Close()
+}
 
-func handler(w http.ResponseWriter, r *http.Request) {
+func (s *server) handler(w http.ResponseWriter, r *http.Request) {
 
     jsonenc := json.NewEncoder(w)
     w.Header().Set("Content-Type", "application/json")
-    f, err := os.OpenFile("pgdump_errorlog.txt", os.O_RDWR | os.O_CREATE | os.O_APPEND, 0666)
-
-    log.Print("Couldn't open file")
-    ///log.SetOutput(f)
-    defer f.Close()
 
     // Timing
     start := time.Now()
 
-    // Postgres Credentials
-    const (
-        DB_USER     = "postgres"
-        DB_PASSWORD = "james"
-        DB_PORT     = "1337"
-        DB_NAME     = "Lacuna"
-    )
-
-    // Postgres Connect
-    dbinfo := fmt.Sprintf("user=%s password=%s dbname=%s port=%s sslmode=disable",
-                           DB_USER, DB_PASSWORD, DB_NAME, DB_PORT)
-    db, err := sql.Open("postgres", dbinfo)
-    if err != nil {
-        handleError(w, err.Error())
-    }
-    defer db.Close()
+    // Queries are cancelled if the client goes away or the request takes
+    // too long.
+    ctx, cancel := context.WithTimeout(r.Context(), s.config.RequestTimeout.Duration)
+    defer cancel()
 
     table := r.FormValue("table")
     feature := r.FormValue("id")
     if table != "" {
 
+        config, ok := s.config.table(table)
+        if !ok {
+            handleError(w, http.StatusNotFound, fmt.Sprintf("No layer called %q", table))
+            return
+        }
+
+        key, err := parseKey(config.KeyType, feature)
+        if err != nil {
+            handleError(w, http.StatusBadRequest, err.Error())
+            return
+        }
+
+        // The first geometry column is the default.
+        column := r.FormValue("geometry")
+        if column == "" {
+            column = config.GeometryColumns[0]
+        } else if !contains(config.GeometryColumns, column) {
+            handleError(w, http.StatusBadRequest, fmt.Sprintf("geometry must be one of %s", strings.Join(config.GeometryColumns, ", ")))
+            return
+        }
+
         //Postgres Query
         var (
-        	id int
-        	geom string
+        	id interface{}
+        	geom wktparse.Geom
         )
 
-        table := pq.QuoteIdentifier(table)
-        identifier := pq.QuoteIdentifier("ID")
-        rows, err := db.Query( fmt.Sprintf("SELECT %s, ST_AsText(geom) FROM %s WHERE %s = $1", identifier, table, identifier), feature)
-        if err != nil {
-            handleError(w, err.Error())
-            return
+        // The geometry comes back as hex EWKB, which keeps full precision
+        // and the SRID where ST_AsText would lose both. The key is read back
+        // as text, as a uuid would otherwise come back as bytes.
+        identifier := pq.QuoteIdentifier(config.Key)
+        selected := identifier
+        if config.KeyType == "text" || config.KeyType == "uuid" {
+            selected += "::text"
+        }
+        query := fmt.Sprintf("SELECT %s, %s FROM %s WHERE %s = $1", selected, pq.QuoteIdentifier(column), config.from(table), identifier)
+        err = s.db.QueryRowContext(ctx, query, key).Scan(&id, &geom)
+        if text, ok := id.([]byte); ok {
+            id = string(text)
         }
-        defer rows.Close()
-        for rows.Next() {
-        	err := rows.Scan(&id, &geom)
-            if err != nil {
-                handleError(w, err.Error())
-                return
-            }
+        if err == sql.ErrNoRows {
+            handleError(w, http.StatusNotFound, "No ID by that number")
+            return
         }
-        err = rows.Err()
         if err != nil {
-            handleError(w, err.Error() )
+            handleQueryError(ctx, w, err)
             return
         }
-        returnjson := ReturnJSON{}
-
-        // Maniplate Strings
-        returngeom := strings.Replace(geom, "1.#QNAN", "", -1)
 
-        if isGeometryCollection(returngeom)  {
+        switch r.FormValue("format") {
+        case "", "wkt":
+            jsonenc.Encode(ReturnJSON{WTKGeoms: toWKTs(geom.Geometry), Elapsed: time.Since(start) / time.Millisecond})
+        case "geojson":
+            w.Header().Set("Content-Type", "application/geo+json")
+            jsonenc.Encode(wktparse.Feature{ID: id, Geometry: geom.Geometry})
+        default:
+            handleError(w, http.StatusBadRequest, "format must be wkt or geojson")
+        }
+    }
+}
 
-            // Geometry Collection e.g. - GEOMETRYCOLLECTION(POINT(4 6),LINESTRING(4 6,7 10))
-            s          := strings.Index(returngeom, "(") + 1
-            e          := strings.LastIndex(returngeom, ")") - 1
-            geomsstr   := returngeom[s:e] // From ( to )
-            geomsre    := regexp.MustCompile(`GEOMETRY|POINT|MULTIPOINT|LINESTRING|MULTILINESTRING|COMPOUNDCURVE|
-                                              MULTIPOLYGON|TRIANGLE|CIRCULARSTRING|CURVE|MULTICURVE|POLYGON|POLYGON Z|
-                                              CURVEPOLYGON|SURFACE|MULTISURFACE|POLYHEDRALSURFACE Z|TIN|TIN Z`)
-            geometries := geomsre.Split(geomsstr, -1)
-            wkttypes    := geomsre.FindAllString(geomsstr, -1)
+// parseKey checks that a feature ID from a request is a valid value of the
+// key column's type and converts it to the value to query with.
+func parseKey(keytype string, value string) (interface{}, error) {
 
-            returnjson  = WKTtoJSON(geometries, wkttypes, start)
+    switch keytype {
+    case "int", "bigint":
+        bits := 32
+        if keytype == "bigint" {
+            bits = 64
+        }
+        n, err := strconv.ParseInt(value, 10, bits)
+        if err != nil {
+            return nil, fmt.Errorf("id must be a whole number that fits in %s", keytype)
+        }
+        return n, nil
+    case "uuid":
+        if !uuidPattern.MatchString(value) {
+            return nil, errors.New("id must be a UUID")
+        }
+        return strings.ToLower(value), nil
+    }
 
-        } else {
+    return value, nil
+}
 
-            s := strings.Index(returngeom, "(")
-            if s != -1 {
-                wkttype    := []string{strings.TrimSpace(returngeom[:s])}
-                geometry   := []string{returngeom[s:]}
-                returnjson  = WKTtoJSON(geometry, wkttype, start)
-            } else {
-                handleError(w, "No ID by that number")
-                return
-            }
+var uuidPattern = regexp.MustCompile(`^[0-9A-Fa-f]{8}-?[0-9A-Fa-f]{4}-?[0-9A-Fa-f]{4}-?[0-9A-Fa-f]{4}-?[0-9A-Fa-f]{12}$`)
 
+func contains(list []string, s string) bool {
+    for _, l := range list {
+        if l == s {
+            return true
         }
+    }
+    return false
+}
+
+// toWKTs splits a geometry into the entries of ReturnJSON.WTKGeoms. Each
+// entry has one flat list of coordinates per ring or part. A NULL or empty
+// geometry has none.
+func toWKTs(g wktparse.Geometry) []WTK {
 
-        jsonenc.Encode(returnjson)
-    }
-}
-
-
-func WKTtoJSON (geometries []string, wkttypes []string, start time.Time ) ReturnJSON {
-
-    wkts       := []WTK{}
-    coordslice := []Coordinate{}
-    geos       := []CoordinateSet{}
-
-    for i, g := range geometries {
-        //log.Print(strconv.FormatInt(int64(len(geometries)), 10))
-        if i <= len(wkttypes) - 1 {
-            g = removeTrails(g)
-            log.Print()
-            subgeoms := strings.Split(g, ")),((")
-            for _, subgeom := range subgeoms {
-                subgeom = removeTrails(subgeom)
-                subgeom = removeBrackets(subgeom)
-                coords  := strings.Split(subgeom, ",")
-                if len(coords) > 0 {
-                    for _, coord := range coords {
-                        xyz        := strings.Split(coord, " ")
-                        if len(xyz) > 2 {
-                            x, err     := strconv.ParseFloat(xyz[0], 64); if err != nil { x = 0.0 }
-                            y, err     := strconv.ParseFloat(xyz[1], 64); if err != nil { y = 0.0 }
-                            z, err     := strconv.ParseFloat(xyz[2], 64); if err != nil { z = 0.0 }
-                            coordslice = append(coordslice, Coordinate{ X: x, Y: y, Z: z}) // CoordinateSet
-                        }
-                    }
-                }
-                if len(coordslice) > 0 {
-                    geos = append(geos, CoordinateSet{Coordinates : coordslice})
-                }
-            }
-            //log.Print(geos)
-            if len(geos) > 0 {
-                wkts     = append(wkts, WTK{ Geometry: geos, WTKType: wkttypes[i] })
-            }
+    wkts := []WTK{}
+
+    if collection, ok := g.(*wktparse.GeometryCollection); ok {
+        for _, member := range collection.Geometries {
+            wkts = append(wkts, toWKTs(member)...)
+        }
+    } else if g != nil {
+        wkttype, set := wktparse.ToCoordinateSet(g)
+        if sets := flatten(set, nil); len(sets) > 0 {
+            wkts = append(wkts, WTK{WTKType: wkttype, Geometry: sets})
         }
     }
 
-    return ReturnJSON{WTKGeoms: wkts, Elapsed: time.Since(start)/1000000.0 }
+    return wkts
 }
 
-func removeBrackets(str string) string {
+// flatten appends one CoordinateSet to sets for each list of coordinates,
+// ring and part in set, in order, including those of curve segments.
+func flatten(set wktparse.CoordinateSet, sets []CoordinateSet) []CoordinateSet {
 
-    str = strings.Replace(str, "(", "", -1)
-    str  = strings.Replace(str, ")", "", -1)
-    return str
-}
-
-func removeTrails(str string) string {
-    if strings.HasSuffix(str, ",") {
-        restr := strings.TrimSpace(str[: strings.LastIndex(str, ",")])
-        return restr
+    if len(set.Coordinates) > 0 {
+        sets = append(sets, toCoordinates(set.Coordinates))
+    }
+    for _, ring := range set.Rings {
+        sets = append(sets, toCoordinates(ring))
+    }
+    for _, part := range set.Parts {
+        sets = flatten(part, sets)
     }
-    return str
+    for _, child := range set.Children {
+        for _, geometry := range child.Geometries {
+            sets = flatten(geometry, sets)
+        }
+    }
+
+    return sets
 }
 
-func isGeometryCollection(inputstr string) bool {
-    return strings.Contains(inputstr,"GEOMETRYCOLLECTION")
+// toCoordinates copies coordinates into pgdump's shape, which has no M.
+func toCoordinates(coordinates []wktparse.Coordinate) CoordinateSet {
+    set := CoordinateSet{Coordinates: make([]Coordinate, len(coordinates))}
+    for i, c := range coordinates {
+        set.Coordinates[i] = Coordinate{X: c.X, Y: c.Y, Z: c.Z}
+    }
+    return set
 }
 
-func handleError(w http.ResponseWriter, err string) {
+// handleError writes err as the JSON error body of a response with the
+// given status. Callers must return straight after, as nothing more can be
+// written.
+func handleError(w http.ResponseWriter, status int, err string) {
     type APIError struct {
         Error string
     }
     re, _ := json.Marshal(APIError{Error: err})
+    w.WriteHeader(status)
     io.WriteString(w, string(re))
 }
 
+// handleQueryError reports a failed query, telling a timeout apart from
+// other database errors. The database error itself is only logged, as it
+// can name tables, columns and settings the client has no business seeing.
+func handleQueryError(ctx context.Context, w http.ResponseWriter, err error) {
+    if ctx.Err() == context.DeadlineExceeded {
+        handleError(w, http.StatusGatewayTimeout, "Query timed out")
+        return
+    }
+    log.Print("pgdump: query failed: ", err)
+    handleError(w, http.StatusInternalServerError, "Query failed")
+}
+
 func main() {
+    config := mustLoadConfig()
+
+    s, err := newServer(config)
+    if err != nil {
+        log.Fatal(err)
+    }
+
     c := cors.New(cors.Options{
-		AllowedOrigins: []string{"http://localhost"},
+		AllowedOrigins: config.CORSOrigins,
 	})
 
-    httphandler := http.HandlerFunc(handler)
-    http.ListenAndServe(":8080", c.Handler(httphandler))
+    mux := http.NewServeMux()
+    mux.HandleFunc("/layers", s.layersHandler)
+    mux.HandleFunc("/", s.handler)
+    httpserver := &http.Server{
+        Addr:              config.Listen,
+        Handler:           c.Handler(mux),
+        ReadHeaderTimeout: config.RequestTimeout.Duration,
+    }
+
+    // On SIGTERM or Ctrl-C stop accepting connections and let requests in
+    // flight finish before the pool is closed.
+    stopped := make(chan error, 1)
+    go func() {
+        signals := make(chan os.Signal, 1)
+        signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+        log.Printf("pgdump: %v, shutting down", <-signals)
+
+        ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+        defer cancel()
+        stopped <- httpserver.Shutdown(ctx)
+    }()
+
+    log.Printf("pgdump: listening on %s", config.Listen)
+    if err := httpserver.ListenAndServe(); err != http.ErrServerClosed {
+        s.Close()
+        log.Fatal(err)
+    }
+
+    if err := <-stopped; err != nil {
+        log.Print("pgdump: ", err)
+    }
+    if err := s.Close(); err != nil {
+        log.Print("pgdump: ", err)
+    }
 }
@@ -0,0 +1,321 @@
+package main
+
+import (
+    "context"
+    "database/sql"
+    "database/sql/driver"
+    "errors"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "reflect"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+    "wktparse"
+)
+
+// The fake driver answers each query with the function registered under
+// the data source name, so every test gets a database of its own.
+type fakeQuery func(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error)
+
+var (
+    fakeMu      sync.Mutex
+    fakeQueries = map[string]fakeQuery{}
+)
+
+func init() {
+    sql.Register("pgdumptest", fakeDriver{})
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+    fakeMu.Lock()
+    defer fakeMu.Unlock()
+    query, ok := fakeQueries[name]
+    if !ok {
+        return nil, errors.New("no fake database " + name)
+    }
+    return fakeConn{query}, nil
+}
+
+type fakeConn struct {
+    query fakeQuery
+}
+
+func (c fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+    return c.query(ctx, query, args)
+}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) {
+    return nil, errors.New("fake database cannot prepare")
+}
+
+func (fakeConn) Close() error {
+    return nil
+}
+
+func (fakeConn) Begin() (driver.Tx, error) {
+    return nil, errors.New("fake database cannot begin")
+}
+
+// fakeRows returns values one row at a time.
+type fakeRows struct {
+    columns []string
+    values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+    return r.columns
+}
+
+func (r *fakeRows) Close() error {
+    return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+    if len(r.values) == 0 {
+        return io.EOF
+    }
+    copy(dest, r.values[0])
+    r.values = r.values[1:]
+    return nil
+}
+
+// newTestServer returns a server whose pool queries the fake database.
+func newTestServer(t *testing.T, query fakeQuery) *server {
+    fakeMu.Lock()
+    fakeQueries[t.Name()] = query
+    fakeMu.Unlock()
+
+    db, err := sql.Open("pgdumptest", t.Name())
+    if err != nil {
+        t.Fatal(err)
+    }
+    config := validConfig()
+    config.RequestTimeout.Duration = 50 * time.Millisecond
+    return &server{config: config, db: db}
+}
+
+// get sends a request to s and returns the recorded response.
+func get(s *server, url string) *httptest.ResponseRecorder {
+    w := httptest.NewRecorder()
+    s.handler(w, httptest.NewRequest("GET", url, nil))
+    return w
+}
+
+func TestHandleQueryError(t *testing.T) {
+
+    secret := errors.New(`pq: relation "secret_table" does not exist`)
+
+    ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+    defer cancel()
+    <-ctx.Done()
+
+    cancelled, cancel := context.WithCancel(context.Background())
+    cancel()
+
+    tests := []struct {
+        ctx    context.Context
+        err    error
+        status int
+        body   string
+    }{
+        {ctx, context.DeadlineExceeded, http.StatusGatewayTimeout, "Query timed out"},
+        {ctx, secret, http.StatusGatewayTimeout, "Query timed out"},
+        {context.Background(), secret, http.StatusInternalServerError, "Query failed"},
+        {context.Background(), context.DeadlineExceeded, http.StatusInternalServerError, "Query failed"},
+        {cancelled, context.Canceled, http.StatusInternalServerError, "Query failed"},
+    }
+
+    for i, test := range tests {
+
+        w := httptest.NewRecorder()
+        handleQueryError(test.ctx, w, test.err)
+
+        if w.Code != test.status || !strings.Contains(w.Body.String(), test.body) {
+            t.Error("Case ", i, ": expected ", test.status, " ", test.body, " got ", w.Code, " ", w.Body.String())
+        }
+        if strings.Contains(w.Body.String(), "secret_table") {
+            t.Error("Case ", i, ": database error sent to the client: ", w.Body.String())
+        }
+    }
+
+}
+
+func TestHandlerTimeout(t *testing.T) {
+
+    s := newTestServer(t, func(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+        <-ctx.Done()
+        return nil, ctx.Err()
+    })
+    defer s.Close()
+
+    w := get(s, "/?table=roofs&id=1")
+    if w.Code != http.StatusGatewayTimeout {
+        t.Error("Expected ", http.StatusGatewayTimeout, " got ", w.Code, " ", w.Body.String())
+    }
+
+}
+
+func TestHandlerQueryFailed(t *testing.T) {
+
+    s := newTestServer(t, func(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+        return nil, errors.New(`pq: relation "secret_table" does not exist`)
+    })
+    defer s.Close()
+
+    w := get(s, "/?table=roofs&id=1")
+    if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "secret_table") {
+        t.Error("Expected ", http.StatusInternalServerError, " without the database error got ", w.Code, " ", w.Body.String())
+    }
+
+}
+
+func TestHandlerUnpublished(t *testing.T) {
+
+    s := newTestServer(t, func(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+        return nil, errors.New("unexpected query " + query)
+    })
+    defer s.Close()
+
+    w := get(s, "/?table=secrets&id=1")
+    if w.Code != http.StatusNotFound {
+        t.Error("Expected ", http.StatusNotFound, " got ", w.Code, " ", w.Body.String())
+    }
+
+}
+
+func TestHandler(t *testing.T) {
+
+    s := newTestServer(t, func(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+        if len(args) != 1 || args[0].Value != int64(7) {
+            return nil, errors.New("unexpected arguments")
+        }
+        return &fakeRows{
+            columns: []string{"ID", "st_astext"},
+            values:  [][]driver.Value{{int64(7), "POLYGON Z ((0 0 1,1 0 1,1 1 1,0 0 1))"}},
+        }, nil
+    })
+    defer s.Close()
+
+    w := get(s, "/?table=roofs&id=7")
+    if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"WTKType":"POLYGON Z"`) {
+        t.Error("Expected the polygon got ", w.Code, " ", w.Body.String())
+    }
+
+}
+
+func TestToWKTs(t *testing.T) {
+
+    tests := []struct {
+        wkt  string
+        want []WTK
+    }{
+        // A 2D point has no Z, but still gets one coordinate with Z 0
+        {"POINT (1 2)", []WTK{
+            {"POINT", []CoordinateSet{{[]Coordinate{{1, 2, 0}}}}},
+        }},
+        {"POINT Z (1 2 3)", []WTK{
+            {"POINT Z", []CoordinateSet{{[]Coordinate{{1, 2, 3}}}}},
+        }},
+        {"POINT M (1 2 4)", []WTK{
+            {"POINT M", []CoordinateSet{{[]Coordinate{{1, 2, 0}}}}},
+        }},
+        {"POINT EMPTY", []WTK{}},
+        {"LINESTRING (30 10, 10 30)", []WTK{
+            {"LINESTRING", []CoordinateSet{{[]Coordinate{{30, 10, 0}, {10, 30, 0}}}}},
+        }},
+        {"POLYGON ((0 0, 4 0, 4 4, 0 0), (1 1, 2 1, 2 2, 1 1))", []WTK{
+            {"POLYGON", []CoordinateSet{
+                {[]Coordinate{{0, 0, 0}, {4, 0, 0}, {4, 4, 0}, {0, 0, 0}}},
+                {[]Coordinate{{1, 1, 0}, {2, 1, 0}, {2, 2, 0}, {1, 1, 0}}},
+            }},
+        }},
+        {"MULTIPOINT ((10 40), (40 30))", []WTK{
+            {"MULTIPOINT", []CoordinateSet{{[]Coordinate{{10, 40, 0}, {40, 30, 0}}}}},
+        }},
+        // One set per ring of every polygon, not one set with parts
+        {"MULTIPOLYGON Z (((0 0 1, 1 0 1, 1 1 1, 0 0 1)), ((5 5 2, 6 5 2, 6 6 2, 5 5 2), (5.2 5.1 2, 5.4 5.1 2, 5.4 5.3 2, 5.2 5.1 2)))", []WTK{
+            {"MULTIPOLYGON Z", []CoordinateSet{
+                {[]Coordinate{{0, 0, 1}, {1, 0, 1}, {1, 1, 1}, {0, 0, 1}}},
+                {[]Coordinate{{5, 5, 2}, {6, 5, 2}, {6, 6, 2}, {5, 5, 2}}},
+                {[]Coordinate{{5.2, 5.1, 2}, {5.4, 5.1, 2}, {5.4, 5.3, 2}, {5.2, 5.1, 2}}},
+            }},
+        }},
+        // Collections are split into their members, nested ones included
+        {"GEOMETRYCOLLECTION (POINT (4 6), GEOMETRYCOLLECTION (LINESTRING (4 6, 7 10)), POINT EMPTY)", []WTK{
+            {"POINT", []CoordinateSet{{[]Coordinate{{4, 6, 0}}}}},
+            {"LINESTRING", []CoordinateSet{{[]Coordinate{{4, 6, 0}, {7, 10, 0}}}}},
+        }},
+        {"COMPOUNDCURVE (CIRCULARSTRING (0 0, 1 1, 2 0), (2 0, 4 0))", []WTK{
+            {"COMPOUNDCURVE", []CoordinateSet{
+                {[]Coordinate{{0, 0, 0}, {1, 1, 0}, {2, 0, 0}}},
+                {[]Coordinate{{2, 0, 0}, {4, 0, 0}}},
+            }},
+        }},
+    }
+
+    for _, test := range tests {
+
+        g, err := wktparse.Parse(test.wkt)
+        if err != nil {
+            t.Fatal(test.wkt, ": ", err)
+        }
+
+        if got := toWKTs(g); !reflect.DeepEqual(got, test.want) {
+            t.Error(test.wkt, ": expected ", test.want, " got ", got)
+        }
+    }
+
+    // A NULL geometry
+    if got := toWKTs(nil); len(got) != 0 {
+        t.Error("Expected no entries for NULL got ", got)
+    }
+
+}
+
+func TestParseKey(t *testing.T) {
+
+    tests := []struct {
+        keytype string
+        value   string
+        want    interface{}
+        ok      bool
+    }{
+        {"int", "42", int64(42), true},
+        {"int", "-7", int64(-7), true},
+        {"int", "2147483647", int64(2147483647), true},
+        {"int", "2147483648", nil, false},
+        {"int", "1.5", nil, false},
+        {"int", "", nil, false},
+        {"bigint", "2147483648", int64(2147483648), true},
+        {"bigint", "9223372036854775808", nil, false},
+        {"uuid", "6BA7B810-9DAD-11D1-80B4-00C04FD430C8", "6ba7b810-9dad-11d1-80b4-00c04fd430c8", true},
+        {"uuid", "6ba7b8109dad11d180b400c04fd430c8", "6ba7b8109dad11d180b400c04fd430c8", true},
+        {"uuid", "6ba7b810-9dad-11d1-80b4", nil, false},
+        {"uuid", "6ba7b810-9dad-11d1-80b4-00c04fd430cg", nil, false},
+        {"text", "Robert'); DROP TABLE roofs;--", "Robert'); DROP TABLE roofs;--", true},
+        {"text", "", "", true},
+    }
+
+    for _, test := range tests {
+
+        got, err := parseKey(test.keytype, test.value)
+        if !test.ok {
+            if err == nil {
+                t.Error("Expected an error for ", test.keytype, " ", test.value, " got ", got)
+            }
+            continue
+        }
+
+        if err != nil {
+            t.Error(test.keytype, " ", test.value, ": ", err)
+        } else if got != test.want {
+            t.Errorf("%s %s: expected %#v got %#v", test.keytype, test.value, test.want, got)
+        }
+    }
+
+}
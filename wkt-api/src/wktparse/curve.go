@@ -0,0 +1,295 @@
+package wktparse
+
+import (
+	"math"
+)
+
+// Arcs are split into segments of at most this angle unless the options say
+// otherwise, which gives 32 segments per quarter circle like PostGIS.
+const defaultSegmentAngle = math.Pi / 64
+
+// Upper bound on the number of segments a single arc is split into, so a
+// tiny chord error on a huge arc cannot exhaust memory.
+const maxArcSegments = 1 << 16
+
+// LinearizeOptions control how finely Linearize approximates arcs. If both
+// are set the one that gives the shorter segments wins. If neither is set
+// defaultSegmentAngle is used.
+type LinearizeOptions struct {
+	MaxSegmentAngle float64 // Largest angle, in radians, one segment may span
+	MaxChordError   float64 // Largest distance between a segment and its arc
+}
+
+// Linearize replaces the arcs in a curve geometry with straight segments, so
+// that clients which only understand straight lines can draw it:
+//
+//	CircularString, CompoundCurve  become  LineString
+//	CurvePolygon                   becomes Polygon
+//	MultiCurve                     becomes MultiLineString
+//	MultiSurface                   becomes MultiPolygon
+//
+// Members of a GeometryCollection are linearized in turn. Any other geometry
+// is returned unchanged. Z and M values are interpolated along each arc.
+func Linearize(g Geometry, options LinearizeOptions) Geometry {
+	out := options.linearize(g)
+	out.SetSRID(g.SRID())
+	return out
+}
+
+func (o LinearizeOptions) linearize(g Geometry) Geometry {
+
+	switch g := g.(type) {
+	case *CircularString, *CompoundCurve:
+		return NewLineString(g.Layout(), o.curve(g.(Curve)))
+
+	case *CurvePolygon:
+		return o.polygon(g)
+
+	case *MultiCurve:
+		multiline := NewMultiLineString(g.Layout(), nil)
+		for _, curve := range g.Curves {
+			multiline.LineStrings = append(multiline.LineStrings, NewLineString(g.Layout(), o.curve(curve)))
+		}
+		return multiline
+
+	case *MultiSurface:
+		multipolygon := NewMultiPolygon(g.Layout(), nil)
+		for _, surface := range g.Surfaces {
+			switch surface := surface.(type) {
+			case *Polygon:
+				multipolygon.Polygons = append(multipolygon.Polygons, surface)
+			case *CurvePolygon:
+				multipolygon.Polygons = append(multipolygon.Polygons, o.polygon(surface))
+			}
+		}
+		return multipolygon
+
+	case *GeometryCollection:
+		collection := NewGeometryCollection(g.Layout(), nil)
+		for _, child := range g.Geometries {
+			collection.Geometries = append(collection.Geometries, o.linearize(child))
+		}
+		return collection
+	}
+
+	return g
+}
+
+func (o LinearizeOptions) polygon(g *CurvePolygon) *Polygon {
+	polygon := NewPolygon(g.Layout(), nil)
+	for _, ring := range g.Rings {
+		polygon.Rings = append(polygon.Rings, o.curve(ring))
+	}
+	return polygon
+}
+
+// curve returns the vertices of a LineString, CircularString or
+// CompoundCurve.
+func (o LinearizeOptions) curve(c Curve) []Coordinate {
+
+	switch c := c.(type) {
+	case *CircularString:
+		return o.arcs(c.Coordinates)
+
+	case *CompoundCurve:
+		var coordinates []Coordinate
+		for _, segment := range c.Segments {
+			vertices := o.curve(segment)
+			// Each segment starts where the last one ended.
+			if len(coordinates) > 0 && len(vertices) > 0 && coordinates[len(coordinates)-1] == vertices[0] {
+				vertices = vertices[1:]
+			}
+			coordinates = append(coordinates, vertices...)
+		}
+		return coordinates
+
+	case *LineString:
+		return c.Coordinates
+	}
+
+	return nil
+}
+
+// arcs linearizes the control points of a CIRCULARSTRING, where points
+// 0, 1, 2 form the first arc, points 2, 3, 4 the second and so on.
+func (o LinearizeOptions) arcs(points []Coordinate) []Coordinate {
+
+	if len(points) < 3 {
+		return append([]Coordinate(nil), points...)
+	}
+
+	coordinates := []Coordinate{points[0]}
+
+	i := 0
+	for ; i+2 < len(points); i += 2 {
+		coordinates = append(coordinates, o.arc(points[i], points[i+1], points[i+2])...)
+	}
+
+	// A malformed string with an even number of points ends in a straight
+	// segment rather than losing its last point.
+	return append(coordinates, points[i+1:]...)
+}
+
+// arc returns the vertices of the arc from p0 through p1 to p2, excluding
+// p0.
+func (o LinearizeOptions) arc(p0, p1, p2 Coordinate) []Coordinate {
+
+	c, ok := circleThrough(p0, p1, p2)
+	if !ok {
+		// The points are collinear, so the arc is a straight line.
+		return []Coordinate{p1, p2}
+	}
+
+	total := math.Abs(c.sweep)
+
+	n := math.Ceil(total / o.segmentAngle(c.r))
+	if n > maxArcSegments {
+		n = maxArcSegments
+	}
+
+	coordinates := make([]Coordinate, 0, int(n))
+
+	for i := 1.0; i < n; i++ {
+
+		a := c.a0 + c.sweep*i/n
+		v := Coordinate{X: c.cx + c.r*math.Cos(a), Y: c.cy + c.r*math.Sin(a)}
+
+		// Z and M change linearly with angle, from p0 to p1 and then from
+		// p1 to p2.
+		travelled := total * i / n
+		if travelled <= c.through {
+			v.Z, v.M = lerp(p0.Z, p1.Z, travelled/c.through), lerp(p0.M, p1.M, travelled/c.through)
+		} else {
+			f := (travelled - c.through) / (total - c.through)
+			v.Z, v.M = lerp(p1.Z, p2.Z, f), lerp(p1.M, p2.M, f)
+		}
+
+		coordinates = append(coordinates, v)
+	}
+
+	return append(coordinates, p2)
+}
+
+// circle describes the arc through three points: its centre and radius, the
+// angle of the first point, the signed angle swept to the last point (which
+// is positive anticlockwise) and the unsigned angle swept to the middle one.
+type circle struct {
+	cx, cy, r float64
+	a0, sweep float64
+	through   float64
+}
+
+// circleThrough finds the arc from p0 through p1 to p2. If p0 and p2 are the
+// same point the arc is a full circle with p1 diametrically opposite them.
+// It returns false if the points are collinear or coincide.
+func circleThrough(p0, p1, p2 Coordinate) (circle, bool) {
+
+	var c circle
+
+	if p0.X == p2.X && p0.Y == p2.Y {
+
+		c.cx, c.cy = (p0.X+p1.X)/2, (p0.Y+p1.Y)/2
+		c.sweep, c.through = 2*math.Pi, math.Pi
+
+	} else {
+
+		d := 2 * (p0.X*(p1.Y-p2.Y) + p1.X*(p2.Y-p0.Y) + p2.X*(p0.Y-p1.Y))
+		if d == 0 {
+			return c, false
+		}
+
+		s0 := p0.X*p0.X + p0.Y*p0.Y
+		s1 := p1.X*p1.X + p1.Y*p1.Y
+		s2 := p2.X*p2.X + p2.Y*p2.Y
+		c.cx = (s0*(p1.Y-p2.Y) + s1*(p2.Y-p0.Y) + s2*(p0.Y-p1.Y)) / d
+		c.cy = (s0*(p2.X-p1.X) + s1*(p0.X-p2.X) + s2*(p1.X-p0.X)) / d
+
+		a0 := math.Atan2(p0.Y-c.cy, p0.X-c.cx)
+		a1 := math.Atan2(p1.Y-c.cy, p1.X-c.cx)
+		a2 := math.Atan2(p2.Y-c.cy, p2.X-c.cx)
+
+		// d is positive when p0, p1, p2 turn anticlockwise.
+		if d > 0 {
+			c.sweep, c.through = normalizeAngle(a2-a0), normalizeAngle(a1-a0)
+		} else {
+			c.sweep, c.through = -normalizeAngle(a0-a2), normalizeAngle(a0-a1)
+		}
+	}
+
+	c.r = math.Hypot(p0.X-c.cx, p0.Y-c.cy)
+	if c.r == 0 || math.IsInf(c.r, 0) || math.IsNaN(c.r) {
+		return c, false
+	}
+	c.a0 = math.Atan2(p0.Y-c.cy, p0.X-c.cx)
+
+	return c, true
+}
+
+// arcsBounds returns the bounds of the arcs of a CircularString. These can
+// reach beyond the control points wherever an arc crosses one of the axes
+// through its centre.
+func arcsBounds(points []Coordinate) Bounds {
+
+	b := coordinateBounds(points)
+
+	for i := 0; i+2 < len(points); i += 2 {
+
+		c, ok := circleThrough(points[i], points[i+1], points[i+2])
+		if !ok {
+			continue
+		}
+
+		for quarter := 0.0; quarter < 4; quarter++ {
+			a := quarter * math.Pi / 2
+			// Angle from the start of the arc to this axis crossing, measured
+			// in the direction of the arc.
+			along := normalizeAngle(a - c.a0)
+			if c.sweep < 0 {
+				along = normalizeAngle(c.a0 - a)
+			}
+			if along <= math.Abs(c.sweep) {
+				extreme := Coordinate{X: c.cx + c.r*math.Cos(a), Y: c.cy + c.r*math.Sin(a)}
+				b.Min.X, b.Min.Y = math.Min(b.Min.X, extreme.X), math.Min(b.Min.Y, extreme.Y)
+				b.Max.X, b.Max.Y = math.Max(b.Max.X, extreme.X), math.Max(b.Max.Y, extreme.Y)
+			}
+		}
+	}
+
+	return b
+}
+
+// segmentAngle works out the largest angle one segment of an arc of radius
+// r may span. A chord spanning angle a lies r(1 - cos(a/2)) from its arc.
+func (o LinearizeOptions) segmentAngle(r float64) float64 {
+
+	angle := o.MaxSegmentAngle
+
+	if o.MaxChordError > 0 {
+		chord := math.Pi
+		if o.MaxChordError < r {
+			chord = 2 * math.Acos(1-o.MaxChordError/r)
+		}
+		if angle <= 0 || chord < angle {
+			angle = chord
+		}
+	}
+
+	if angle <= 0 {
+		angle = defaultSegmentAngle
+	}
+
+	return angle
+}
+
+// normalizeAngle maps an angle in radians into [0, 2π).
+func normalizeAngle(a float64) float64 {
+	a = math.Mod(a, 2*math.Pi)
+	if a < 0 {
+		a += 2 * math.Pi
+	}
+	return a
+}
+
+func lerp(a, b, f float64) float64 {
+	return a + (b-a)*f
+}
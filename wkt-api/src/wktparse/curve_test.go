@@ -0,0 +1,261 @@
+package wktparse
+
+import "testing"
+import "math"
+import "strconv"
+
+func TestCircularString(t *testing.T) {
+
+	var circular string = "CIRCULARSTRING (0 0, 1 1, 2 0, 3 -1, 4 0)"
+	circulartype, circularwkt, err := ParseGeometry(circular)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if circulartype != "CIRCULARSTRING" {
+		t.Error("Expected CIRCULARSTRING got ", circulartype)
+	}
+	if len(circularwkt.Coordinates) != 5 {
+		t.Error("Expected the 5 control points to be kept got ", strconv.Itoa(len(circularwkt.Coordinates)))
+	}
+
+	for _, wkt := range []string{"CIRCULARSTRING (0 0, 1 1)", "CIRCULARSTRING (0 0, 1 1, 2 0, 3 1)"} {
+		if _, _, err := ParseGeometry(wkt); err == nil {
+			t.Error("Expected an error for ", wkt)
+		}
+	}
+
+}
+
+func TestCompoundCurve(t *testing.T) {
+
+	var compound string = "COMPOUNDCURVE Z (CIRCULARSTRING (0 0 1, 1 1 1, 2 0 1), (2 0 1, 4 0 2))"
+	compoundtype, compoundwkt, err := ParseGeometry(compound)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if compoundtype != "COMPOUNDCURVE Z" {
+		t.Error("Expected COMPOUNDCURVE Z got ", compoundtype)
+	}
+	if len(compoundwkt.Children) != 2 {
+		t.Fatal("CompoundCurve does not have 2 segments, has ", strconv.Itoa(len(compoundwkt.Children)))
+	}
+	if compoundwkt.Children[0].WTKType != "CIRCULARSTRING Z" {
+		t.Error("Expected CIRCULARSTRING Z got ", compoundwkt.Children[0].WTKType)
+	}
+	if compoundwkt.Children[1].WTKType != "LINESTRING Z" {
+		t.Error("Expected LINESTRING Z got ", compoundwkt.Children[1].WTKType)
+	}
+	if compoundwkt.Children[1].Geometries[0].Coordinates[1].Z != 2.0 {
+		t.Error("Expected Z to be 2.0 got ", compoundwkt.Children[1].Geometries[0].Coordinates[1].Z)
+	}
+
+	if _, _, err := ParseGeometry("COMPOUNDCURVE Z (CIRCULARSTRING M (0 0 1, 1 1 1, 2 0 1))"); err == nil {
+		t.Error("Expected an error for a member with a different dimension")
+	}
+	if _, _, err := ParseGeometry("COMPOUNDCURVE (POINT (0 0))"); err == nil {
+		t.Error("Expected an error for a POINT member")
+	}
+
+}
+
+func TestCurvePolygon(t *testing.T) {
+
+	var curvepolygon string = "CURVEPOLYGON (COMPOUNDCURVE (CIRCULARSTRING (0 0, 2 0, 2 1, 2 3, 4 3), (4 3, 4 5, 1 4, 0 0)), CIRCULARSTRING (1.7 1, 1.4 0.4, 1.6 0.4, 1.6 0.5, 1.7 1), (2 2, 2.5 2, 2 2.5, 2 2))"
+	curvetype, curvewkt, err := ParseGeometry(curvepolygon)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if curvetype != "CURVEPOLYGON" {
+		t.Error("Expected CURVEPOLYGON got ", curvetype)
+	}
+
+	types := []string{"COMPOUNDCURVE", "CIRCULARSTRING", "LINESTRING"}
+	if len(curvewkt.Children) != len(types) {
+		t.Fatal("CurvePolygon does not have 3 rings, has ", strconv.Itoa(len(curvewkt.Children)))
+	}
+	for i, ring := range curvewkt.Children {
+		if ring.WTKType != types[i] {
+			t.Error("Expected ", types[i], " got ", ring.WTKType)
+		}
+	}
+
+}
+
+func TestMultiCurveAndSurface(t *testing.T) {
+
+	multicurvetype, multicurvewkt, err := ParseGeometry("MULTICURVE ((0 0, 5 5), CIRCULARSTRING (4 0, 4 4, 8 4))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if multicurvetype != "MULTICURVE" || len(multicurvewkt.Children) != 2 {
+		t.Error("Expected MULTICURVE with 2 curves got ", multicurvetype, multicurvewkt.Children)
+	}
+
+	multisurfacetype, multisurfacewkt, err := ParseGeometry("MULTISURFACE (CURVEPOLYGON (CIRCULARSTRING (0 0, 4 0, 4 4, 0 4, 0 0)), ((10 10, 14 12, 11 10, 10 10)))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if multisurfacetype != "MULTISURFACE" || len(multisurfacewkt.Children) != 2 {
+		t.Fatal("Expected MULTISURFACE with 2 surfaces got ", multisurfacetype, multisurfacewkt.Children)
+	}
+	if multisurfacewkt.Children[1].WTKType != "POLYGON" {
+		t.Error("Expected POLYGON got ", multisurfacewkt.Children[1].WTKType)
+	}
+
+}
+
+func TestLinearizeCircularString(t *testing.T) {
+
+	circular, err := Parse("CIRCULARSTRING Z (0 0 0, 1 1 5, 2 0 10)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	line, ok := Linearize(circular, LinearizeOptions{MaxSegmentAngle: math.Pi / 8}).(*LineString)
+	if !ok {
+		t.Fatal("Expected a *LineString")
+	}
+
+	if line.Layout() != XYZ {
+		t.Error("Expected XYZ got ", line.Layout())
+	}
+
+	// A half circle split into 22.5 degree segments.
+	if len(line.Coordinates) != 9 {
+		t.Fatal("Expected 9 coordinates got ", strconv.Itoa(len(line.Coordinates)))
+	}
+
+	for i, c := range line.Coordinates {
+		if r := math.Hypot(c.X-1, c.Y); math.Abs(r-1) > 1e-9 {
+			t.Error("Expected coordinate ", i, " to lie on the circle, radius is ", r)
+		}
+		if c.Y < -1e-9 {
+			t.Error("Expected coordinate ", i, " to be on the upper half of the circle, Y is ", c.Y)
+		}
+	}
+
+	if line.Coordinates[4].X != 1 || math.Abs(line.Coordinates[4].Z-5) > 1e-9 {
+		t.Error("Expected the middle coordinate to be (1 1 5) got ", line.Coordinates[4])
+	}
+	if line.Coordinates[8] != (Coordinate{X: 2, Y: 0, Z: 10}) {
+		t.Error("Expected the last coordinate to be (2 0 10) got ", line.Coordinates[8])
+	}
+
+}
+
+func TestLinearizeChordError(t *testing.T) {
+
+	circular, err := Parse("CIRCULARSTRING (0 0, 10 10, 20 0)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	coords := Linearize(circular, LinearizeOptions{MaxChordError: 0.01}).(*LineString).Coordinates
+	for i := 1; i < len(coords); i++ {
+		mx, my := (coords[i-1].X+coords[i].X)/2, (coords[i-1].Y+coords[i].Y)/2
+		if e := 10 - math.Hypot(mx-10, my); e > 0.01+1e-9 {
+			t.Error("Expected chord error of at most 0.01 got ", e)
+		}
+	}
+
+	coarse := Linearize(circular, LinearizeOptions{MaxChordError: 1}).(*LineString).Coordinates
+	if len(coarse) >= len(coords) {
+		t.Error("Expected a larger chord error to give fewer coordinates, got ", len(coarse), " and ", len(coords))
+	}
+
+}
+
+func TestLinearizeCurvePolygon(t *testing.T) {
+
+	curvepolygon, err := Parse("CURVEPOLYGON (COMPOUNDCURVE (CIRCULARSTRING (0 0, 2 2, 4 0), (4 0, 0 0)), CIRCULARSTRING (1 0.5, 2 1.5, 1 0.5))")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	polygon, ok := Linearize(curvepolygon, LinearizeOptions{}).(*Polygon)
+	if !ok {
+		t.Fatal("Expected a *Polygon")
+	}
+	if len(polygon.Rings) != 2 {
+		t.Fatal("Expected 2 rings got ", strconv.Itoa(len(polygon.Rings)))
+	}
+
+	for i, ring := range polygon.Rings {
+		if ring[0] != ring[len(ring)-1] {
+			t.Error("Expected ring ", i, " to be closed got ", ring[0], " and ", ring[len(ring)-1])
+		}
+	}
+
+	// The shell is a half circle of radius 2, so 64 segments, closed by one
+	// straight line with no repeated vertex where the two join.
+	if len(polygon.Shell()) != 66 {
+		t.Error("Expected 66 shell coordinates got ", strconv.Itoa(len(polygon.Shell())))
+	}
+
+	// The hole is a full circle around (1.5 1), the midpoint of its first
+	// two control points.
+	for _, c := range polygon.Holes()[0] {
+		if r := math.Hypot(c.X-1.5, c.Y-1); math.Abs(r-math.Sqrt(0.5)) > 1e-9 {
+			t.Error("Expected hole coordinate to lie on the circle, radius is ", r)
+		}
+	}
+
+}
+
+func TestLinearizeMulti(t *testing.T) {
+
+	multisurface, err := Parse("MULTISURFACE (CURVEPOLYGON (CIRCULARSTRING (0 0, 4 0, 0 0)), ((10 10, 14 12, 11 10, 10 10)))")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	multipolygon, ok := Linearize(multisurface, LinearizeOptions{}).(*MultiPolygon)
+	if !ok || len(multipolygon.Polygons) != 2 {
+		t.Fatal("Expected a *MultiPolygon with 2 polygons got ", multipolygon)
+	}
+	if len(multipolygon.Polygons[1].Shell()) != 4 {
+		t.Error("Expected the straight polygon to be unchanged got ", multipolygon.Polygons[1].Shell())
+	}
+
+	multicurve, err := Parse("MULTICURVE M ((0 0 1, 5 5 2), CIRCULARSTRING (4 0 1, 4 4 1, 8 4 1))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	multiline, ok := Linearize(multicurve, LinearizeOptions{}).(*MultiLineString)
+	if !ok || len(multiline.LineStrings) != 2 || multiline.Layout() != XYM {
+		t.Error("Expected an XYM *MultiLineString with 2 lines got ", multiline)
+	}
+
+	point, _ := Parse("POINT (1 2)")
+	if Linearize(point, LinearizeOptions{}) != point {
+		t.Error("Expected POINT to be unchanged")
+	}
+
+}
+
+func TestCircularStringBounds(t *testing.T) {
+
+	circular, err := Parse("CIRCULARSTRING (0 0, 1 1, 2 0, 3 -1, 4 0)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bounds := circular.Bounds()
+	if bounds.Min.X != 0 || bounds.Max.X != 4 || math.Abs(bounds.Min.Y+1) > 1e-9 || math.Abs(bounds.Max.Y-1) > 1e-9 {
+		t.Error("Expected bounds (0 -1, 4 1) got ", bounds)
+	}
+
+	// The control points of a half circle from (0 0) to (2 2) through
+	// (2 0) stay inside x <= 2, but the arc bulges out to 1 + sqrt(2).
+	circular, err = Parse("CIRCULARSTRING (0 0, 2 0, 2 2)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if max := circular.Bounds().Max.X; math.Abs(max-(1+math.Sqrt2)) > 1e-9 {
+		t.Error("Expected Max.X to be 1 + sqrt(2) got ", max)
+	}
+
+}
@@ -0,0 +1,140 @@
+package wktparse
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Records longer than this are skipped unless the options say otherwise.
+const defaultMaxRecordSize = 16 << 20
+
+// DecoderOptions control how a Decoder splits its input into records.
+type DecoderOptions struct {
+	// Separator ends each record, "\n" if empty. Whitespace around a
+	// record is ignored, so "\r\n" line endings need no special handling.
+	Separator string
+	// MaxRecordSize is the longest record, in bytes, the Decoder will read
+	// into memory, 16 MiB if 0.
+	MaxRecordSize int
+}
+
+// A Decoder reads WKT or EWKT geometries one record at a time from a
+// stream, such as a file with one geometry per line. It only ever holds one
+// record in memory, so its memory use is bounded by MaxRecordSize however
+// large the input is. Blank records are skipped.
+type Decoder struct {
+	r      *bufio.Reader
+	sep    []byte
+	max    int
+	buf    []byte
+	pos    int64 // Bytes read from r so far
+	offset int64 // Start of the last geometry returned by Decode
+	err    error // Error from r, returned once the last record is used up
+}
+
+// NewDecoder returns a Decoder that reads from r.
+func NewDecoder(r io.Reader, options DecoderOptions) *Decoder {
+
+	d := &Decoder{
+		r:   bufio.NewReader(r),
+		sep: []byte(options.Separator),
+		max: options.MaxRecordSize,
+	}
+
+	if len(d.sep) == 0 {
+		d.sep = []byte("\n")
+	}
+	if d.max <= 0 {
+		d.max = defaultMaxRecordSize
+	}
+
+	return d
+}
+
+// Decode returns the next geometry in the input, or io.EOF once there are
+// no more. If a record is not valid WKT the error is a *ParseError whose
+// offset is relative to Offset, and a record longer than MaxRecordSize gives
+// an error without being read into memory. Either way Decode can be called
+// again to carry on with the next record.
+func (d *Decoder) Decode() (Geometry, error) {
+
+	for {
+		record, start, err := d.record()
+		if err == io.EOF {
+			return nil, err
+		}
+		if err != nil {
+			d.offset = start
+			return nil, err
+		}
+
+		lead := len(record) - len(bytes.TrimLeft(record, " \t\n\r\f\v"))
+		record = bytes.TrimSpace(record)
+		if len(record) == 0 {
+			continue
+		}
+
+		d.offset = start + int64(lead)
+		return ParseBytes(record)
+	}
+}
+
+// Offset returns the byte offset in the input of the geometry last returned
+// by Decode, or of the record that caused its last error.
+func (d *Decoder) Offset() int64 {
+	return d.offset
+}
+
+// record reads up to and including the next separator and returns the
+// record without it, along with the offset where the record started.
+func (d *Decoder) record() ([]byte, int64, error) {
+
+	start := d.pos
+
+	if d.err != nil {
+		return nil, start, d.err
+	}
+
+	last := d.sep[len(d.sep)-1]
+	long := false
+	d.buf = d.buf[:0]
+
+	for {
+		chunk, err := d.r.ReadSlice(last)
+		d.pos += int64(len(chunk))
+		d.buf = append(d.buf, chunk...)
+
+		if len(d.buf) > d.max+len(d.sep) {
+			long = true
+		}
+
+		if err == nil && bytes.HasSuffix(d.buf, d.sep) {
+			if long {
+				return nil, start, fmt.Errorf("wktparse: record at byte %d is longer than %d bytes", start, d.max)
+			}
+			return d.buf[:len(d.buf)-len(d.sep)], start, nil
+		}
+
+		if err != nil && err != bufio.ErrBufferFull {
+			// The input ended, or failed, part way through a record.
+			d.err = err
+			if long || len(d.buf) > d.max {
+				return nil, start, fmt.Errorf("wktparse: record at byte %d is longer than %d bytes", start, d.max)
+			}
+			if err == io.EOF && len(d.buf) > 0 {
+				return d.buf, start, nil
+			}
+			return nil, start, err
+		}
+
+		// Once a record is too long only enough of it is kept to spot a
+		// separator split across two reads.
+		if long {
+			keep := len(d.sep) - 1
+			copy(d.buf, d.buf[len(d.buf)-keep:])
+			d.buf = d.buf[:keep]
+		}
+	}
+}
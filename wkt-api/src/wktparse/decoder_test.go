@@ -0,0 +1,163 @@
+package wktparse
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDecoder(t *testing.T) {
+
+	input := "POINT (1 2)\r\n\n  LINESTRING (30 10, 10 30)\nSRID=4326;POINT Z (1 2 3)"
+	d := NewDecoder(strings.NewReader(input), DecoderOptions{})
+
+	wants := []string{"POINT (1 2)", "LINESTRING (30 10, 10 30)", "SRID=4326;POINT Z (1 2 3)"}
+	offsets := []int64{0, 16, 42}
+
+	for i, wkt := range wants {
+		g, err := d.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := Parse(wkt)
+		if !reflect.DeepEqual(g, want) {
+			t.Error("Expected ", want, " got ", g)
+		}
+		if d.Offset() != offsets[i] {
+			t.Error("Expected offset ", offsets[i], " got ", d.Offset())
+		}
+		if !strings.HasPrefix(input[d.Offset():], wkt) {
+			t.Error("Expected ", wkt, " at offset ", d.Offset())
+		}
+	}
+
+	if _, err := d.Decode(); err != io.EOF {
+		t.Error("Expected io.EOF got ", err)
+	}
+	if _, err := d.Decode(); err != io.EOF {
+		t.Error("Expected io.EOF again got ", err)
+	}
+
+}
+
+func TestDecoderSeparator(t *testing.T) {
+
+	// A separator longer than one byte, read one byte at a time so it is
+	// always split across reads.
+	input := "POINT (1 2)|~|POINT (3 4)|~||~|MULTIPOINT (5 6, 7 8)|~|"
+	d := NewDecoder(iotest.OneByteReader(strings.NewReader(input)), DecoderOptions{Separator: "|~|"})
+
+	var types []string
+	for {
+		g, err := d.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		types = append(types, g.Type())
+	}
+
+	if !reflect.DeepEqual(types, []string{"POINT", "POINT", "MULTIPOINT"}) {
+		t.Error("Expected POINT, POINT, MULTIPOINT got ", types)
+	}
+	if d.Offset() != 31 {
+		t.Error("Expected the last geometry at offset 31 got ", d.Offset())
+	}
+
+}
+
+func TestDecoderErrors(t *testing.T) {
+
+	input := "POINT (1 2)\nPOINT (1\n" + strings.Repeat("POINT (1 2) ", 1000) + "\nPOINT (3 4)\n"
+	d := NewDecoder(iotest.HalfReader(strings.NewReader(input)), DecoderOptions{MaxRecordSize: 100})
+
+	if _, err := d.Decode(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := d.Decode()
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatal("Expected a *ParseError got ", err)
+	}
+	_, want := Parse("POINT (1")
+	if d.Offset() != 12 || perr.Offset != want.(*ParseError).Offset {
+		t.Error("Expected an error at offset 12 + ", want.(*ParseError).Offset, " got ", d.Offset(), " + ", perr.Offset)
+	}
+
+	// The long record is skipped without being held in memory.
+	if _, err := d.Decode(); err == nil || !strings.Contains(err.Error(), "longer than 100 bytes") {
+		t.Error("Expected a record too long error got ", err)
+	}
+	if d.Offset() != 21 {
+		t.Error("Expected the long record at offset 21 got ", d.Offset())
+	}
+	if cap(d.buf) > 4096+200 {
+		t.Error("Expected the buffer to stay small, its capacity is ", cap(d.buf))
+	}
+
+	g, err := d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.(*Point).X != 3 {
+		t.Error("Expected POINT (3 4) after the long record got ", g)
+	}
+
+	if _, err := d.Decode(); err != io.EOF {
+		t.Error("Expected io.EOF got ", err)
+	}
+
+}
+
+func TestDecoderLongLastRecord(t *testing.T) {
+
+	d := NewDecoder(strings.NewReader("POINT (1 2)\n"+strings.Repeat(" ", 10000)+"POINT (3 4)"), DecoderOptions{MaxRecordSize: 64})
+
+	if _, err := d.Decode(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Decode(); err == nil || err == io.EOF {
+		t.Error("Expected a record too long error got ", err)
+	}
+	if _, err := d.Decode(); err != io.EOF {
+		t.Error("Expected io.EOF got ", err)
+	}
+
+}
+
+func TestDecoderMaxRecordSize(t *testing.T) {
+
+	// "POINT (1 2)" is 11 bytes and "POINT (1 20)" 12. The limit applies to
+	// the record without its separator, whether or not it has one.
+	tests := []struct {
+		input     string
+		separator string
+		long      bool
+	}{
+		{"POINT (1 2)", "", false},
+		{"POINT (1 2)\n", "", false},
+		{"POINT (1 20)", "", true},
+		{"POINT (1 20)\n", "", true},
+		{"POINT (1 2)|~|", "|~|", false},
+		{"POINT (1 20)|~|", "|~|", true},
+		{"POINT (1 20)", "|~|", true},
+		{"POINT (1 20)|~", "|~|", true},
+	}
+
+	for _, test := range tests {
+		d := NewDecoder(iotest.OneByteReader(strings.NewReader(test.input)), DecoderOptions{Separator: test.separator, MaxRecordSize: 11})
+		_, err := d.Decode()
+		if test.long && (err == nil || !strings.Contains(err.Error(), "longer than 11 bytes")) {
+			t.Errorf("%q: expected a record too long error got %v", test.input, err)
+		}
+		if !test.long && err != nil {
+			t.Errorf("%q: %v", test.input, err)
+		}
+	}
+
+}
@@ -0,0 +1,383 @@
+package wktparse
+
+import (
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Every geometry type, and Geom for a geometry of any type, implements
+// encoding.TextMarshaler and TextUnmarshaler as WKT, json.Marshaler and
+// Unmarshaler as GeoJSON, and sql.Scanner and driver.Valuer. Unmarshalling
+// or scanning into one of the concrete types fails if the input holds a
+// different type.
+//
+// Scan accepts what PostGIS returns for a geometry column, which is hex
+// EWKB, as well as binary WKB or EWKB from ST_AsBinary or ST_AsEWKB and
+// WKT or EWKT from ST_AsText or ST_AsEWKT. Value writes hex EWKB, which
+// PostGIS accepts wherever it expects a geometry.
+
+// Geom holds a geometry of any type, for decoding into when the type is not
+// known in advance:
+//
+//	var geom wktparse.Geom
+//	err := rows.Scan(&geom)
+//
+// A NULL column scans as a nil Geometry, and a nil Geometry is written as
+// NULL.
+type Geom struct {
+	Geometry
+}
+
+func (g Geom) MarshalText() ([]byte, error) {
+	return marshalText(g.Geometry)
+}
+
+func (g *Geom) UnmarshalText(text []byte) error {
+	geometry, err := ParseBytes(text)
+	if err != nil {
+		return err
+	}
+	g.Geometry = geometry
+	return nil
+}
+
+func (g Geom) MarshalJSON() ([]byte, error) {
+	if g.Geometry == nil {
+		return []byte("null"), nil
+	}
+	return MarshalGeoJSON(g.Geometry)
+}
+
+func (g *Geom) UnmarshalJSON(data []byte) error {
+	if isNull(data) {
+		g.Geometry = nil
+		return nil
+	}
+	geometry, err := ParseGeoJSON(data)
+	if err != nil {
+		return err
+	}
+	g.Geometry = geometry
+	return nil
+}
+
+func (g *Geom) Scan(src interface{}) error {
+	if src == nil {
+		g.Geometry = nil
+		return nil
+	}
+	geometry, err := scan(src)
+	if err != nil {
+		return err
+	}
+	g.Geometry = geometry
+	return nil
+}
+
+func (g Geom) Value() (driver.Value, error) {
+	if g.Geometry == nil {
+		return nil, nil
+	}
+	return value(g.Geometry)
+}
+
+func marshalText(g Geometry) ([]byte, error) {
+	text, err := Marshal(g)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(text), nil
+}
+
+func unmarshalText(dst Geometry, text []byte) error {
+	g, err := ParseBytes(text)
+	if err != nil {
+		return err
+	}
+	return assign(dst, g)
+}
+
+func unmarshalJSON(dst Geometry, data []byte) error {
+	g, err := ParseGeoJSON(data)
+	if err != nil {
+		return err
+	}
+	return assign(dst, g)
+}
+
+func scanInto(dst Geometry, src interface{}) error {
+	if src == nil {
+		return fmt.Errorf("wktparse: cannot scan NULL into %T, use Geom", dst)
+	}
+	g, err := scan(src)
+	if err != nil {
+		return err
+	}
+	return assign(dst, g)
+}
+
+// scan decodes a value from a database driver, telling WKB, hex WKB and
+// WKT apart by their first byte.
+func scan(src interface{}) (Geometry, error) {
+
+	var data []byte
+	switch src := src.(type) {
+	case []byte:
+		data = src
+	case string:
+		data = []byte(src)
+	default:
+		return nil, fmt.Errorf("wktparse: cannot scan %T into a geometry", src)
+	}
+
+	if len(data) == 0 {
+		return nil, errors.New("wktparse: cannot scan an empty value into a geometry")
+	}
+
+	// Binary WKB starts with its byte order marker, which no text does.
+	if data[0] == wkbXDR || data[0] == wkbNDR {
+		return ParseWKB(data)
+	}
+
+	text := strings.TrimSpace(string(data))
+	if isHex(text) {
+		wkb, err := hex.DecodeString(text)
+		if err != nil {
+			return nil, err
+		}
+		return ParseWKB(wkb)
+	}
+
+	return Parse(text)
+}
+
+// isHex reports whether s could be hex WKB. WKT always has a letter other
+// than A to F in its keyword, so it never passes.
+func isHex(s string) bool {
+	if len(s) == 0 || len(s)%2 != 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+func value(g Geometry) (driver.Value, error) {
+	return HexEWKB(g, binary.LittleEndian)
+}
+
+// assign copies src into dst if they are the same type.
+func assign(dst Geometry, src Geometry) error {
+
+	mismatch := fmt.Errorf("wktparse: cannot store %s in %T", src.Type(), dst)
+
+	switch dst := dst.(type) {
+	case *Point:
+		src, ok := src.(*Point)
+		if !ok {
+			return mismatch
+		}
+		*dst = *src
+	case *LineString:
+		src, ok := src.(*LineString)
+		if !ok {
+			return mismatch
+		}
+		*dst = *src
+	case *Polygon:
+		src, ok := src.(*Polygon)
+		if !ok {
+			return mismatch
+		}
+		*dst = *src
+	case *Triangle:
+		src, ok := src.(*Triangle)
+		if !ok {
+			return mismatch
+		}
+		*dst = *src
+	case *MultiPoint:
+		src, ok := src.(*MultiPoint)
+		if !ok {
+			return mismatch
+		}
+		*dst = *src
+	case *MultiLineString:
+		src, ok := src.(*MultiLineString)
+		if !ok {
+			return mismatch
+		}
+		*dst = *src
+	case *MultiPolygon:
+		src, ok := src.(*MultiPolygon)
+		if !ok {
+			return mismatch
+		}
+		*dst = *src
+	case *PolyhedralSurface:
+		src, ok := src.(*PolyhedralSurface)
+		if !ok {
+			return mismatch
+		}
+		*dst = *src
+	case *TIN:
+		src, ok := src.(*TIN)
+		if !ok {
+			return mismatch
+		}
+		*dst = *src
+	case *GeometryCollection:
+		src, ok := src.(*GeometryCollection)
+		if !ok {
+			return mismatch
+		}
+		*dst = *src
+	case *CircularString:
+		src, ok := src.(*CircularString)
+		if !ok {
+			return mismatch
+		}
+		*dst = *src
+	case *CompoundCurve:
+		src, ok := src.(*CompoundCurve)
+		if !ok {
+			return mismatch
+		}
+		*dst = *src
+	case *CurvePolygon:
+		src, ok := src.(*CurvePolygon)
+		if !ok {
+			return mismatch
+		}
+		*dst = *src
+	case *MultiCurve:
+		src, ok := src.(*MultiCurve)
+		if !ok {
+			return mismatch
+		}
+		*dst = *src
+	case *MultiSurface:
+		src, ok := src.(*MultiSurface)
+		if !ok {
+			return mismatch
+		}
+		*dst = *src
+	default:
+		return mismatch
+	}
+
+	return nil
+}
+
+func (p *Point) MarshalText() ([]byte, error)    { return marshalText(p) }
+func (p *Point) UnmarshalText(text []byte) error { return unmarshalText(p, text) }
+func (p *Point) MarshalJSON() ([]byte, error)    { return MarshalGeoJSON(p) }
+func (p *Point) UnmarshalJSON(data []byte) error { return unmarshalJSON(p, data) }
+func (p *Point) Scan(src interface{}) error      { return scanInto(p, src) }
+func (p *Point) Value() (driver.Value, error)    { return value(p) }
+
+func (l *LineString) MarshalText() ([]byte, error)    { return marshalText(l) }
+func (l *LineString) UnmarshalText(text []byte) error { return unmarshalText(l, text) }
+func (l *LineString) MarshalJSON() ([]byte, error)    { return MarshalGeoJSON(l) }
+func (l *LineString) UnmarshalJSON(data []byte) error { return unmarshalJSON(l, data) }
+func (l *LineString) Scan(src interface{}) error      { return scanInto(l, src) }
+func (l *LineString) Value() (driver.Value, error)    { return value(l) }
+
+func (p *Polygon) MarshalText() ([]byte, error)    { return marshalText(p) }
+func (p *Polygon) UnmarshalText(text []byte) error { return unmarshalText(p, text) }
+func (p *Polygon) MarshalJSON() ([]byte, error)    { return MarshalGeoJSON(p) }
+func (p *Polygon) UnmarshalJSON(data []byte) error { return unmarshalJSON(p, data) }
+func (p *Polygon) Scan(src interface{}) error      { return scanInto(p, src) }
+func (p *Polygon) Value() (driver.Value, error)    { return value(p) }
+
+func (t *Triangle) MarshalText() ([]byte, error)    { return marshalText(t) }
+func (t *Triangle) UnmarshalText(text []byte) error { return unmarshalText(t, text) }
+func (t *Triangle) MarshalJSON() ([]byte, error)    { return MarshalGeoJSON(t) }
+func (t *Triangle) UnmarshalJSON(data []byte) error { return unmarshalJSON(t, data) }
+func (t *Triangle) Scan(src interface{}) error      { return scanInto(t, src) }
+func (t *Triangle) Value() (driver.Value, error)    { return value(t) }
+
+func (m *MultiPoint) MarshalText() ([]byte, error)    { return marshalText(m) }
+func (m *MultiPoint) UnmarshalText(text []byte) error { return unmarshalText(m, text) }
+func (m *MultiPoint) MarshalJSON() ([]byte, error)    { return MarshalGeoJSON(m) }
+func (m *MultiPoint) UnmarshalJSON(data []byte) error { return unmarshalJSON(m, data) }
+func (m *MultiPoint) Scan(src interface{}) error      { return scanInto(m, src) }
+func (m *MultiPoint) Value() (driver.Value, error)    { return value(m) }
+
+func (m *MultiLineString) MarshalText() ([]byte, error)    { return marshalText(m) }
+func (m *MultiLineString) UnmarshalText(text []byte) error { return unmarshalText(m, text) }
+func (m *MultiLineString) MarshalJSON() ([]byte, error)    { return MarshalGeoJSON(m) }
+func (m *MultiLineString) UnmarshalJSON(data []byte) error { return unmarshalJSON(m, data) }
+func (m *MultiLineString) Scan(src interface{}) error      { return scanInto(m, src) }
+func (m *MultiLineString) Value() (driver.Value, error)    { return value(m) }
+
+func (m *MultiPolygon) MarshalText() ([]byte, error)    { return marshalText(m) }
+func (m *MultiPolygon) UnmarshalText(text []byte) error { return unmarshalText(m, text) }
+func (m *MultiPolygon) MarshalJSON() ([]byte, error)    { return MarshalGeoJSON(m) }
+func (m *MultiPolygon) UnmarshalJSON(data []byte) error { return unmarshalJSON(m, data) }
+func (m *MultiPolygon) Scan(src interface{}) error      { return scanInto(m, src) }
+func (m *MultiPolygon) Value() (driver.Value, error)    { return value(m) }
+
+func (s *PolyhedralSurface) MarshalText() ([]byte, error)    { return marshalText(s) }
+func (s *PolyhedralSurface) UnmarshalText(text []byte) error { return unmarshalText(s, text) }
+func (s *PolyhedralSurface) MarshalJSON() ([]byte, error)    { return MarshalGeoJSON(s) }
+func (s *PolyhedralSurface) UnmarshalJSON(data []byte) error { return unmarshalJSON(s, data) }
+func (s *PolyhedralSurface) Scan(src interface{}) error      { return scanInto(s, src) }
+func (s *PolyhedralSurface) Value() (driver.Value, error)    { return value(s) }
+
+func (t *TIN) MarshalText() ([]byte, error)    { return marshalText(t) }
+func (t *TIN) UnmarshalText(text []byte) error { return unmarshalText(t, text) }
+func (t *TIN) MarshalJSON() ([]byte, error)    { return MarshalGeoJSON(t) }
+func (t *TIN) UnmarshalJSON(data []byte) error { return unmarshalJSON(t, data) }
+func (t *TIN) Scan(src interface{}) error      { return scanInto(t, src) }
+func (t *TIN) Value() (driver.Value, error)    { return value(t) }
+
+func (c *GeometryCollection) MarshalText() ([]byte, error)    { return marshalText(c) }
+func (c *GeometryCollection) UnmarshalText(text []byte) error { return unmarshalText(c, text) }
+func (c *GeometryCollection) MarshalJSON() ([]byte, error)    { return MarshalGeoJSON(c) }
+func (c *GeometryCollection) UnmarshalJSON(data []byte) error { return unmarshalJSON(c, data) }
+func (c *GeometryCollection) Scan(src interface{}) error      { return scanInto(c, src) }
+func (c *GeometryCollection) Value() (driver.Value, error)    { return value(c) }
+
+func (c *CircularString) MarshalText() ([]byte, error)    { return marshalText(c) }
+func (c *CircularString) UnmarshalText(text []byte) error { return unmarshalText(c, text) }
+func (c *CircularString) MarshalJSON() ([]byte, error)    { return MarshalGeoJSON(c) }
+func (c *CircularString) UnmarshalJSON(data []byte) error { return unmarshalJSON(c, data) }
+func (c *CircularString) Scan(src interface{}) error      { return scanInto(c, src) }
+func (c *CircularString) Value() (driver.Value, error)    { return value(c) }
+
+func (c *CompoundCurve) MarshalText() ([]byte, error)    { return marshalText(c) }
+func (c *CompoundCurve) UnmarshalText(text []byte) error { return unmarshalText(c, text) }
+func (c *CompoundCurve) MarshalJSON() ([]byte, error)    { return MarshalGeoJSON(c) }
+func (c *CompoundCurve) UnmarshalJSON(data []byte) error { return unmarshalJSON(c, data) }
+func (c *CompoundCurve) Scan(src interface{}) error      { return scanInto(c, src) }
+func (c *CompoundCurve) Value() (driver.Value, error)    { return value(c) }
+
+func (c *CurvePolygon) MarshalText() ([]byte, error)    { return marshalText(c) }
+func (c *CurvePolygon) UnmarshalText(text []byte) error { return unmarshalText(c, text) }
+func (c *CurvePolygon) MarshalJSON() ([]byte, error)    { return MarshalGeoJSON(c) }
+func (c *CurvePolygon) UnmarshalJSON(data []byte) error { return unmarshalJSON(c, data) }
+func (c *CurvePolygon) Scan(src interface{}) error      { return scanInto(c, src) }
+func (c *CurvePolygon) Value() (driver.Value, error)    { return value(c) }
+
+func (m *MultiCurve) MarshalText() ([]byte, error)    { return marshalText(m) }
+func (m *MultiCurve) UnmarshalText(text []byte) error { return unmarshalText(m, text) }
+func (m *MultiCurve) MarshalJSON() ([]byte, error)    { return MarshalGeoJSON(m) }
+func (m *MultiCurve) UnmarshalJSON(data []byte) error { return unmarshalJSON(m, data) }
+func (m *MultiCurve) Scan(src interface{}) error      { return scanInto(m, src) }
+func (m *MultiCurve) Value() (driver.Value, error)    { return value(m) }
+
+func (m *MultiSurface) MarshalText() ([]byte, error)    { return marshalText(m) }
+func (m *MultiSurface) UnmarshalText(text []byte) error { return unmarshalText(m, text) }
+func (m *MultiSurface) MarshalJSON() ([]byte, error)    { return MarshalGeoJSON(m) }
+func (m *MultiSurface) UnmarshalJSON(data []byte) error { return unmarshalJSON(m, data) }
+func (m *MultiSurface) Scan(src interface{}) error      { return scanInto(m, src) }
+func (m *MultiSurface) Value() (driver.Value, error)    { return value(m) }
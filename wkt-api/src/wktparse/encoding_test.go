@@ -0,0 +1,182 @@
+package wktparse
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// Every geometry type must implement all of the interfaces.
+var (
+	_ encoding.TextMarshaler   = (*Point)(nil)
+	_ encoding.TextUnmarshaler = (*MultiSurface)(nil)
+	_ json.Marshaler           = (*TIN)(nil)
+	_ json.Unmarshaler         = (*CircularString)(nil)
+	_ sql.Scanner              = (*GeometryCollection)(nil)
+	_ driver.Valuer            = (*Polygon)(nil)
+	_ sql.Scanner              = (*Geom)(nil)
+	_ driver.Valuer            = Geom{}
+)
+
+func TestTextMarshaler(t *testing.T) {
+
+	var line LineString
+	if err := line.UnmarshalText([]byte("SRID=4326;LINESTRING (30 10, 10 30)")); err != nil {
+		t.Fatal(err)
+	}
+	if line.SRID() != 4326 || len(line.Coordinates) != 2 {
+		t.Error("Expected a LINESTRING with SRID 4326 got ", line)
+	}
+
+	text, err := line.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(text) != "SRID=4326;LINESTRING (30 10, 10 30)" {
+		t.Error("Expected SRID=4326;LINESTRING (30 10, 10 30) got ", string(text))
+	}
+
+	var point Point
+	if err := point.UnmarshalText([]byte("LINESTRING (30 10, 10 30)")); err == nil {
+		t.Error("Expected an error unmarshalling a LINESTRING into a Point")
+	}
+
+	var geom Geom
+	if err := geom.UnmarshalText([]byte("TIN EMPTY")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := geom.Geometry.(*TIN); !ok {
+		t.Error("Expected a *TIN got ", geom.Geometry)
+	}
+
+}
+
+func TestJSONMarshaler(t *testing.T) {
+
+	var wrapper struct {
+		Name  string
+		Shape *Polygon
+		Any   Geom
+	}
+
+	input := `{"Name":"a","Shape":{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]]]},"Any":{"type":"Point","coordinates":[1,2,3]}}`
+	if err := json.Unmarshal([]byte(input), &wrapper); err != nil {
+		t.Fatal(err)
+	}
+	if len(wrapper.Shape.Shell()) != 4 {
+		t.Error("Expected a shell of 4 coordinates got ", wrapper.Shape)
+	}
+	if wrapper.Any.Layout() != XYZ {
+		t.Error("Expected an XYZ point got ", wrapper.Any.Geometry)
+	}
+
+	output, err := json.Marshal(wrapper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(output) != input {
+		t.Error("Expected ", input, " got ", string(output))
+	}
+
+	var point Point
+	if err := json.Unmarshal([]byte(`{"type":"LineString","coordinates":[[1,2],[3,4]]}`), &point); err == nil {
+		t.Error("Expected an error unmarshalling a LineString into a Point")
+	}
+
+	var geom Geom
+	if err := json.Unmarshal([]byte("null"), &geom); err != nil || geom.Geometry != nil {
+		t.Error("Expected null to give a nil Geometry got ", geom.Geometry, err)
+	}
+	if output, _ := json.Marshal(geom); string(output) != "null" {
+		t.Error("Expected null got ", string(output))
+	}
+
+}
+
+func TestScan(t *testing.T) {
+
+	want, err := Parse("SRID=4326;POINT (1 2)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sources := []interface{}{
+		// A geometry column as lib/pq returns it.
+		[]byte("0101000020E6100000000000000000F03F0000000000000040"),
+		"0101000020e6100000000000000000f03f0000000000000040",
+		// ST_AsEWKB.
+		mustDecodeHex(t, "0101000020E6100000000000000000F03F0000000000000040"),
+		// ST_AsEWKT.
+		"SRID=4326;POINT(1 2)",
+		[]byte("SRID=4326;POINT(1 2)"),
+	}
+
+	for _, src := range sources {
+		var point Point
+		if err := point.Scan(src); err != nil {
+			t.Error(src, ": ", err)
+			continue
+		}
+		if !reflect.DeepEqual(&point, want) {
+			t.Error("Expected ", want, " for ", src, " got ", point)
+		}
+
+		var geom Geom
+		if err := geom.Scan(src); err != nil {
+			t.Error(src, ": ", err)
+			continue
+		}
+		if !reflect.DeepEqual(geom.Geometry, want) {
+			t.Error("Expected ", want, " for ", src, " got ", geom.Geometry)
+		}
+	}
+
+	var geom Geom
+	if err := geom.Scan(nil); err != nil || geom.Geometry != nil {
+		t.Error("Expected NULL to give a nil Geometry got ", geom.Geometry, err)
+	}
+
+	var point Point
+	for _, src := range []interface{}{nil, 12, "", "LINESTRING (1 2, 3 4)", "01020000"} {
+		if err := point.Scan(src); err == nil {
+			t.Error("Expected an error scanning ", src, " into a Point")
+		}
+	}
+
+}
+
+func TestValue(t *testing.T) {
+
+	g, err := Parse("SRID=4326;POINT (1 2)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := g.(*Point).Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "0101000020E6100000000000000000F03F0000000000000040" {
+		t.Error("Expected hex EWKB got ", v)
+	}
+
+	v, err = Geom{g}.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var geom Geom
+	if err := geom.Scan(v); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(geom.Geometry, g) {
+		t.Error("Expected ", g, " to survive Value and Scan got ", geom.Geometry)
+	}
+
+	if v, err := (Geom{}).Value(); v != nil || err != nil {
+		t.Error("Expected NULL for a nil Geometry got ", v, err)
+	}
+
+}
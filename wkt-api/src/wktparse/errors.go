@@ -0,0 +1,52 @@
+package wktparse
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ParseError is returned when a WKT string cannot be parsed. It records
+// where in the input the problem was found and what the parser expected to
+// see there, so callers can pass a useful message back to whoever sent the
+// string.
+type ParseError struct {
+	Offset   int    // Byte offset into the input, starting at 0
+	Line     int    // Line number, starting at 1
+	Column   int    // Byte column within the line, starting at 1
+	Expected string // What the parser was looking for
+	Found    string // What it found instead
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("wktparse: line %d, column %d: expected %s, found %s", e.Line, e.Column, e.Expected, e.Found)
+}
+
+func newParseError(input []byte, offset int, expected string, found string) *ParseError {
+
+	if offset > len(input) {
+		offset = len(input)
+	}
+
+	line := bytes.Count(input[:offset], []byte("\n")) + 1
+	column := offset - bytes.LastIndexByte(input[:offset], '\n')
+
+	return &ParseError{
+		Offset:   offset,
+		Line:     line,
+		Column:   column,
+		Expected: expected,
+		Found:    found,
+	}
+}
+
+// WKBError is returned when WKB cannot be decoded, whether because it is
+// truncated, malformed or describes a geometry that is not allowed where it
+// appears.
+type WKBError struct {
+	Offset int    // Byte offset into the input, starting at 0
+	Reason string // What was wrong
+}
+
+func (e *WKBError) Error() string {
+	return fmt.Sprintf("wktparse: WKB byte %d: %s", e.Offset, e.Reason)
+}
@@ -0,0 +1,474 @@
+package wktparse
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// GeoJSON (RFC 7946) has no types for triangles or curves, and positions
+// with more than three ordinates are discouraged, so geometries are written
+// as the nearest GeoJSON type:
+//
+//	Triangle                        Polygon
+//	TIN, PolyhedralSurface          MultiPolygon, one polygon per face
+//	CircularString, CompoundCurve   LineString, linearized
+//	CurvePolygon                    Polygon, linearized
+//	MultiCurve                      MultiLineString, linearized
+//	MultiSurface                    MultiPolygon, linearized
+//
+// M ordinates are dropped and Z is kept. GeoJSON has no empty point, so
+// POINT EMPTY is written with an empty coordinates array and empty members
+// of a MultiPoint are left out. GeoJSON coordinates are always WGS 84, so
+// the SRID of a geometry is not written either.
+
+// Feature is a GeoJSON Feature: a geometry with an optional identifier and
+// a set of properties.
+type Feature struct {
+	ID         interface{} // A string or number, or nil if the feature has none
+	Geometry   Geometry    // nil for a feature with no location
+	Properties map[string]interface{}
+}
+
+// FeatureCollection is a GeoJSON FeatureCollection.
+type FeatureCollection struct {
+	Features []*Feature
+}
+
+// GeoJSONError is returned when GeoJSON cannot be read. Path says where in
+// the document the problem is, e.g. features[2].geometry.coordinates[0].
+type GeoJSONError struct {
+	Path   string // Empty for the document as a whole
+	Reason string
+}
+
+func (e *GeoJSONError) Error() string {
+	if e.Path == "" {
+		return "wktparse: GeoJSON: " + e.Reason
+	}
+	return "wktparse: GeoJSON at " + e.Path + ": " + e.Reason
+}
+
+// Nesting limit for GeometryCollections.
+const maxGeoJSONDepth = 128
+
+// ParseGeoJSON reads a GeoJSON geometry object. Positions with three
+// numbers give an XYZ geometry, and all positions within one geometry must
+// have the same number. If the input is not a valid geometry the error is a
+// *GeoJSONError.
+func ParseGeoJSON(data []byte) (Geometry, error) {
+	r := &geojsonReader{}
+	return r.geometry(data, "")
+}
+
+// ParseFeature reads a GeoJSON Feature, keeping its id and properties. A
+// numeric id is a float64 and a string id a string.
+func ParseFeature(data []byte) (*Feature, error) {
+	r := &geojsonReader{}
+	return r.feature(data, "")
+}
+
+// ParseFeatureCollection reads a GeoJSON FeatureCollection. For
+// convenience it also accepts a single Feature or a bare geometry, which it
+// returns as a collection of one feature.
+func ParseFeatureCollection(data []byte) (*FeatureCollection, error) {
+
+	r := &geojsonReader{}
+
+	var object geojsonObject
+	if err := r.unmarshal(data, &object, ""); err != nil {
+		return nil, err
+	}
+
+	switch object.Type {
+	case "FeatureCollection":
+		if object.Features == nil {
+			return nil, &GeoJSONError{Path: "features", Reason: "missing features array"}
+		}
+		collection := &FeatureCollection{Features: make([]*Feature, 0, len(object.Features))}
+		for i, raw := range object.Features {
+			f, err := r.feature(raw, "features["+strconv.Itoa(i)+"]")
+			if err != nil {
+				return nil, err
+			}
+			collection.Features = append(collection.Features, f)
+		}
+		return collection, nil
+
+	case "Feature":
+		f, err := r.feature(data, "")
+		if err != nil {
+			return nil, err
+		}
+		return &FeatureCollection{Features: []*Feature{f}}, nil
+	}
+
+	g, err := r.geometry(data, "")
+	if err != nil {
+		return nil, err
+	}
+	return &FeatureCollection{Features: []*Feature{{Geometry: g}}}, nil
+}
+
+// UnmarshalJSON reads a GeoJSON Feature into f.
+func (f *Feature) UnmarshalJSON(data []byte) error {
+	feature, err := ParseFeature(data)
+	if err != nil {
+		return err
+	}
+	*f = *feature
+	return nil
+}
+
+// UnmarshalJSON reads a GeoJSON FeatureCollection into c.
+func (c *FeatureCollection) UnmarshalJSON(data []byte) error {
+	collection, err := ParseFeatureCollection(data)
+	if err != nil {
+		return err
+	}
+	*c = *collection
+	return nil
+}
+
+// geojsonObject has the members of every kind of GeoJSON object. Members
+// that are not needed to tell what the object is are left raw.
+type geojsonObject struct {
+	Type        string            `json:"type"`
+	Coordinates json.RawMessage   `json:"coordinates"`
+	Geometries  []json.RawMessage `json:"geometries"`
+	Geometry    json.RawMessage   `json:"geometry"`
+	Properties  json.RawMessage   `json:"properties"`
+	ID          json.RawMessage   `json:"id"`
+	Features    []json.RawMessage `json:"features"`
+}
+
+type geojsonReader struct {
+	depth int
+}
+
+// join adds a member name to a path.
+func join(path string, member string) string {
+	if path == "" {
+		return member
+	}
+	return path + "." + member
+}
+
+func index(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+// unmarshal decodes data into v, turning errors from encoding/json into a
+// *GeoJSONError for the given path.
+func (r *geojsonReader) unmarshal(data []byte, v interface{}, path string) error {
+
+	err := json.Unmarshal(data, v)
+
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *json.SyntaxError:
+		return &GeoJSONError{Path: path, Reason: fmt.Sprintf("invalid JSON at byte %d: %s", e.Offset, e.Error())}
+	case *json.UnmarshalTypeError:
+		// Field is the dotted path within v, with array indexes as
+		// numbers, e.g. "coordinates.1".
+		if e.Field != "" {
+			for _, name := range strings.Split(e.Field, ".") {
+				if i, err := strconv.Atoi(name); err == nil {
+					path = index(path, i)
+				} else {
+					path = join(path, name)
+				}
+			}
+		}
+		return &GeoJSONError{Path: path, Reason: fmt.Sprintf("expected %s, found %s", jsonKind(e.Type), e.Value)}
+	}
+
+	return &GeoJSONError{Path: path, Reason: err.Error()}
+}
+
+// jsonKind names the JSON type that decodes into t.
+func jsonKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		if t == reflect.TypeOf(json.RawMessage(nil)) {
+			return "value"
+		}
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	case reflect.String:
+		return "string"
+	case reflect.Float64, reflect.Float32, reflect.Int, reflect.Int64:
+		return "number"
+	case reflect.Bool:
+		return "boolean"
+	}
+	return t.String()
+}
+
+func isNull(raw json.RawMessage) bool {
+	return len(raw) == 0 || bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+}
+
+func (r *geojsonReader) feature(data []byte, path string) (*Feature, error) {
+
+	var object geojsonObject
+	if err := r.unmarshal(data, &object, path); err != nil {
+		return nil, err
+	}
+	if object.Type != "Feature" {
+		return nil, &GeoJSONError{Path: join(path, "type"), Reason: fmt.Sprintf("expected Feature, found %q", object.Type)}
+	}
+
+	f := &Feature{}
+
+	if !isNull(object.ID) {
+		var id interface{}
+		if err := r.unmarshal(object.ID, &id, join(path, "id")); err != nil {
+			return nil, err
+		}
+		switch id.(type) {
+		case string, float64:
+			f.ID = id
+		default:
+			return nil, &GeoJSONError{Path: join(path, "id"), Reason: "expected string or number"}
+		}
+	}
+
+	if !isNull(object.Geometry) {
+		g, err := r.geometry(object.Geometry, join(path, "geometry"))
+		if err != nil {
+			return nil, err
+		}
+		f.Geometry = g
+	}
+
+	if !isNull(object.Properties) {
+		if err := r.unmarshal(object.Properties, &f.Properties, join(path, "properties")); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+func (r *geojsonReader) geometry(data []byte, path string) (Geometry, error) {
+
+	var object geojsonObject
+	if err := r.unmarshal(data, &object, path); err != nil {
+		return nil, err
+	}
+
+	if object.Type == "GeometryCollection" {
+
+		if object.Geometries == nil {
+			return nil, &GeoJSONError{Path: join(path, "geometries"), Reason: "missing geometries array"}
+		}
+
+		r.depth++
+		defer func() { r.depth-- }()
+		if r.depth > maxGeoJSONDepth {
+			return nil, &GeoJSONError{Path: path, Reason: fmt.Sprintf("geometry collections nested more than %d deep", maxGeoJSONDepth)}
+		}
+
+		collection := NewGeometryCollection(XY, nil)
+		for i, raw := range object.Geometries {
+			g, err := r.geometry(raw, index(join(path, "geometries"), i))
+			if err != nil {
+				return nil, err
+			}
+			collection.Geometries = append(collection.Geometries, g)
+		}
+		return collection, nil
+	}
+
+	switch object.Type {
+	case "":
+		return nil, &GeoJSONError{Path: join(path, "type"), Reason: "missing geometry type"}
+	case "Point", "LineString", "Polygon", "MultiPoint", "MultiLineString", "MultiPolygon":
+	default:
+		return nil, &GeoJSONError{Path: join(path, "type"), Reason: fmt.Sprintf("unknown geometry type %q", object.Type)}
+	}
+
+	path = join(path, "coordinates")
+	if isNull(object.Coordinates) {
+		return nil, &GeoJSONError{Path: path, Reason: "missing coordinates"}
+	}
+
+	p := &positionReader{r: r}
+	var g Geometry
+
+	switch object.Type {
+	case "Point":
+		var ordinates []float64
+		if err := r.unmarshal(object.Coordinates, &ordinates, path); err != nil {
+			return nil, err
+		}
+		if len(ordinates) == 0 {
+			return NewEmptyPoint(XY), nil
+		}
+		c, err := p.position(ordinates, path)
+		if err != nil {
+			return nil, err
+		}
+		g = NewPoint(p.layout, c)
+
+	case "LineString":
+		coordinates, err := p.line(object.Coordinates, path)
+		if err != nil {
+			return nil, err
+		}
+		g = NewLineString(p.layout, coordinates)
+
+	case "Polygon":
+		rings, err := p.rings(object.Coordinates, path)
+		if err != nil {
+			return nil, err
+		}
+		g = NewPolygon(p.layout, rings)
+
+	case "MultiPoint":
+		coordinates, err := p.positions(object.Coordinates, path)
+		if err != nil {
+			return nil, err
+		}
+		multipoint := NewMultiPoint(p.layout, nil)
+		for _, c := range coordinates {
+			multipoint.Points = append(multipoint.Points, NewPoint(p.layout, c))
+		}
+		g = multipoint
+
+	case "MultiLineString":
+		var lines []json.RawMessage
+		if err := r.unmarshal(object.Coordinates, &lines, path); err != nil {
+			return nil, err
+		}
+		multiline := NewMultiLineString(XY, nil)
+		for i, raw := range lines {
+			coordinates, err := p.line(raw, index(path, i))
+			if err != nil {
+				return nil, err
+			}
+			multiline.LineStrings = append(multiline.LineStrings, NewLineString(XY, coordinates))
+		}
+		g = multiline
+
+	case "MultiPolygon":
+		var polygons []json.RawMessage
+		if err := r.unmarshal(object.Coordinates, &polygons, path); err != nil {
+			return nil, err
+		}
+		multipolygon := NewMultiPolygon(XY, nil)
+		for i, raw := range polygons {
+			rings, err := p.rings(raw, index(path, i))
+			if err != nil {
+				return nil, err
+			}
+			multipolygon.Polygons = append(multipolygon.Polygons, NewPolygon(XY, rings))
+		}
+		g = multipolygon
+	}
+
+	// Parts made before the first position was read are fixed up the same
+	// way as in the WKT parser.
+	fixLayout(g, p.layout)
+
+	return g, nil
+}
+
+// positionReader reads the positions of one geometry, checking that they
+// all have the same number of ordinates.
+type positionReader struct {
+	r      *geojsonReader
+	layout Layout
+	known  bool
+}
+
+func (p *positionReader) position(ordinates []float64, path string) (Coordinate, error) {
+
+	if len(ordinates) < 2 || len(ordinates) > 3 {
+		return Coordinate{}, &GeoJSONError{Path: path, Reason: fmt.Sprintf("position must have 2 or 3 numbers, has %d", len(ordinates))}
+	}
+
+	layout := XY
+	if len(ordinates) == 3 {
+		layout = XYZ
+	}
+	if p.known && layout != p.layout {
+		return Coordinate{}, &GeoJSONError{Path: path, Reason: fmt.Sprintf("position has %d numbers but earlier positions have %d", len(ordinates), p.layout.Stride())}
+	}
+	p.layout, p.known = layout, true
+
+	c := Coordinate{X: ordinates[0], Y: ordinates[1]}
+	if layout == XYZ {
+		c.Z = ordinates[2]
+	}
+	return c, nil
+}
+
+func (p *positionReader) positions(data json.RawMessage, path string) ([]Coordinate, error) {
+
+	var positions [][]float64
+	if err := p.r.unmarshal(data, &positions, path); err != nil {
+		return nil, err
+	}
+
+	if len(positions) == 0 {
+		return nil, nil
+	}
+
+	coordinates := make([]Coordinate, len(positions))
+	for i, ordinates := range positions {
+		c, err := p.position(ordinates, index(path, i))
+		if err != nil {
+			return nil, err
+		}
+		coordinates[i] = c
+	}
+
+	return coordinates, nil
+}
+
+// line reads the positions of a LineString, of which there must be none or
+// at least two.
+func (p *positionReader) line(data json.RawMessage, path string) ([]Coordinate, error) {
+	coordinates, err := p.positions(data, path)
+	if err == nil && len(coordinates) == 1 {
+		return nil, &GeoJSONError{Path: path, Reason: "line string must have at least 2 positions, has 1"}
+	}
+	return coordinates, err
+}
+
+// rings reads the linear rings of a Polygon, each of which must be closed
+// and have at least four positions.
+func (p *positionReader) rings(data json.RawMessage, path string) ([][]Coordinate, error) {
+
+	var raw []json.RawMessage
+	if err := p.r.unmarshal(data, &raw, path); err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	rings := make([][]Coordinate, len(raw))
+	for i := range raw {
+		ring, err := p.positions(raw[i], index(path, i))
+		if err != nil {
+			return nil, err
+		}
+		if len(ring) < 4 {
+			return nil, &GeoJSONError{Path: index(path, i), Reason: fmt.Sprintf("linear ring must have at least 4 positions, has %d", len(ring))}
+		}
+		if first, last := ring[0], ring[len(ring)-1]; first != last {
+			return nil, &GeoJSONError{Path: index(path, i), Reason: "linear ring is not closed"}
+		}
+		rings[i] = ring
+	}
+
+	return rings, nil
+}
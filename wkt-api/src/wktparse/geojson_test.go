@@ -0,0 +1,205 @@
+package wktparse
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseGeoJSON(t *testing.T) {
+
+	tests := map[string]string{
+		`{"type":"Point","coordinates":[1,2]}`:                                                                                            "POINT (1 2)",
+		`{"type":"Point","coordinates":[1,2,3]}`:                                                                                          "POINT Z (1 2 3)",
+		`{"type":"Point","coordinates":[]}`:                                                                                               "POINT EMPTY",
+		`{"type":"LineString","coordinates":[[30,10],[10,30],[40,40]]}`:                                                                   "LINESTRING (30 10, 10 30, 40 40)",
+		`{"type":"LineString","coordinates":[]}`:                                                                                          "LINESTRING EMPTY",
+		`{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]]]}`:                                                                    "POLYGON ((0 0, 1 0, 1 1, 0 0))",
+		`{"type":"MultiPoint","coordinates":[[10,40,1],[40,30,2]]}`:                                                                       "MULTIPOINT Z (10 40 1, 40 30 2)",
+		`{"type":"MultiLineString","coordinates":[[],[[1,2,3],[4,5,6]]]}`:                                                                 "MULTILINESTRING Z (EMPTY, (1 2 3, 4 5 6))",
+		`{"type":"MultiPolygon","coordinates":[[[[0,0],[1,0],[1,1],[0,0]]]]}`:                                                             "MULTIPOLYGON (((0 0, 1 0, 1 1, 0 0)))",
+		`{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[4,6]},{"type":"GeometryCollection","geometries":[]}]}`: "GEOMETRYCOLLECTION (POINT (4 6), GEOMETRYCOLLECTION EMPTY)",
+		` { "coordinates" : [ 1.5e3 , -2E-2 ] , "type" : "Point" , "bbox" : [ 0 , 0 , 1 , 1 ] } `:                                         "POINT (1500 -0.02)",
+	}
+
+	for geojson, wkt := range tests {
+		want, err := Parse(wkt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ParseGeoJSON([]byte(geojson))
+		if err != nil {
+			t.Error(geojson, ": ", err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Error("Expected ", want, " for ", geojson, " got ", got)
+		}
+	}
+
+}
+
+func TestGeoJSONRoundTrip(t *testing.T) {
+
+	for _, wkt := range []string{
+		"POINT Z (1 2 3)",
+		"MULTIPOINT (10 40, 40 30)",
+		"POLYGON ((0 0, 10 0, 10 10, 0 10, 0 0), (2 2, 4 4, 4 2, 2 2))",
+		"MULTILINESTRING ((10 10, 20 20, 10 40), (40 40, 30 30, 40 20, 30 10))",
+		"GEOMETRYCOLLECTION (POINT (4 6), LINESTRING Z (4 6 1, 7 10 2))",
+	} {
+		g, err := Parse(wkt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := MarshalGeoJSON(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := ParseGeoJSON(data)
+		if err != nil {
+			t.Fatal(string(data), ": ", err)
+		}
+		if !reflect.DeepEqual(parsed, g) {
+			t.Error("Expected ", wkt, " to round trip got ", parsed)
+		}
+	}
+
+}
+
+func TestParseFeature(t *testing.T) {
+
+	f, err := ParseFeature([]byte(`{"type":"Feature","id":"a1","geometry":{"type":"Point","coordinates":[1,2]},"properties":{"name":"x","height":2.5,"tags":["a"]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f.ID != "a1" {
+		t.Error("Expected id a1 got ", f.ID)
+	}
+	if point, ok := f.Geometry.(*Point); !ok || point.Coordinate != (Coordinate{X: 1, Y: 2}) {
+		t.Error("Expected POINT (1 2) got ", f.Geometry)
+	}
+	want := map[string]interface{}{"name": "x", "height": 2.5, "tags": []interface{}{"a"}}
+	if !reflect.DeepEqual(f.Properties, want) {
+		t.Error("Expected ", want, " got ", f.Properties)
+	}
+
+	var feature Feature
+	if err := json.Unmarshal([]byte(`{"type":"Feature","id":7,"geometry":null,"properties":null}`), &feature); err != nil {
+		t.Fatal(err)
+	}
+	if feature.ID != 7.0 || feature.Geometry != nil || feature.Properties != nil {
+		t.Error("Expected a feature with id 7 and nothing else got ", feature)
+	}
+
+}
+
+func TestParseFeatureCollection(t *testing.T) {
+
+	var collection FeatureCollection
+	err := json.Unmarshal([]byte(`{"type":"FeatureCollection","features":[
+		{"type":"Feature","id":1,"geometry":{"type":"Point","coordinates":[1,2]},"properties":{}},
+		{"type":"Feature","geometry":null,"properties":{"a":true}}
+	]}`), &collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(collection.Features) != 2 || collection.Features[1].Properties["a"] != true {
+		t.Error("Expected 2 features got ", collection.Features)
+	}
+
+	// A bare Feature or geometry is read as a collection of one.
+	for _, geojson := range []string{
+		`{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]},"properties":null}`,
+		`{"type":"Point","coordinates":[1,2]}`,
+	} {
+		c, err := ParseFeatureCollection([]byte(geojson))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(c.Features) != 1 || c.Features[0].Geometry.Type() != "POINT" {
+			t.Error("Expected one feature with a point got ", c.Features)
+		}
+	}
+
+	// Writing and reading back gives the same features.
+	data, err := json.Marshal(collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again FeatureCollection
+	if err := json.Unmarshal(data, &again); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again, collection) {
+		t.Error("Expected the collection to round trip got ", string(data))
+	}
+
+}
+
+func TestParseGeoJSONErrors(t *testing.T) {
+
+	tests := []struct {
+		geojson string
+		path    string
+		reason  string
+	}{
+		{`{"type":"Point","coordinates":[1,2]`, "", "invalid JSON at byte 35: unexpected end of JSON input"},
+		{`{"type":"Circle","coordinates":[1,2]}`, "type", `unknown geometry type "Circle"`},
+		{`{"coordinates":[1,2]}`, "type", "missing geometry type"},
+		{`{"type":5,"coordinates":[1,2]}`, "type", "expected string, found number"},
+		{`{"type":"Point"}`, "coordinates", "missing coordinates"},
+		{`{"type":"Point","coordinates":[1]}`, "coordinates", "position must have 2 or 3 numbers, has 1"},
+		{`{"type":"Point","coordinates":[1,"2"]}`, "coordinates[1]", "expected number, found string"},
+		{`{"type":"LineString","coordinates":[[1,2],[3,4,5]]}`, "coordinates[1]", "position has 3 numbers but earlier positions have 2"},
+		{`{"type":"LineString","coordinates":[[1,2],[3,"4"]]}`, "coordinates[1][1]", "expected number, found string"},
+		{`{"type":"LineString","coordinates":[[1,2]]}`, "coordinates", "line string must have at least 2 positions, has 1"},
+		{`{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]],[[0,0],[1,0],[0,0]]]}`, "coordinates[1]", "linear ring must have at least 4 positions, has 3"},
+		{`{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,1]]]}`, "coordinates[0]", "linear ring is not closed"},
+		{`{"type":"MultiPolygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]]]}`, "coordinates[0][0][0]", "expected array, found number"},
+		{`{"type":"GeometryCollection"}`, "geometries", "missing geometries array"},
+		{`{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[1,2,3,4]}]}`, "geometries[0].coordinates", "position must have 2 or 3 numbers, has 4"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseGeoJSON([]byte(test.geojson))
+		gerr, ok := err.(*GeoJSONError)
+		if !ok {
+			t.Error("Expected a *GeoJSONError for ", test.geojson, " got ", err)
+			continue
+		}
+		if gerr.Path != test.path || gerr.Reason != test.reason {
+			t.Error("Expected ", test.path, ": ", test.reason, " got ", gerr.Path, ": ", gerr.Reason)
+		}
+	}
+
+	features := []struct {
+		geojson string
+		path    string
+		reason  string
+	}{
+		{`{"type":"Point","coordinates":[1,2]}`, "type", `expected Feature, found "Point"`},
+		{`{"type":"Feature","id":true,"geometry":null}`, "id", "expected string or number"},
+		{`{"type":"Feature","geometry":null,"properties":[1]}`, "properties", "expected object, found array"},
+		{`{"type":"Feature","geometry":{"type":"Point","coordinates":[1]}}`, "geometry.coordinates", "position must have 2 or 3 numbers, has 1"},
+	}
+
+	for _, test := range features {
+		_, err := ParseFeature([]byte(test.geojson))
+		gerr, ok := err.(*GeoJSONError)
+		if !ok {
+			t.Error("Expected a *GeoJSONError for ", test.geojson, " got ", err)
+			continue
+		}
+		if gerr.Path != test.path || gerr.Reason != test.reason {
+			t.Error("Expected ", test.path, ": ", test.reason, " got ", gerr.Path, ": ", gerr.Reason)
+		}
+	}
+
+	_, err := ParseFeatureCollection([]byte(`{"type":"FeatureCollection","features":[{"type":"Feature","geometry":null},{"type":"Feature","geometry":{"type":"LineString","coordinates":[[1,2]]}}]}`))
+	if gerr, ok := err.(*GeoJSONError); !ok || gerr.Path != "features[1].geometry.coordinates" {
+		t.Error("Expected an error at features[1].geometry.coordinates got ", err)
+	}
+
+}
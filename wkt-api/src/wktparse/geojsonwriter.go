@@ -0,0 +1,396 @@
+package wktparse
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// GeoJSONOptions control how geometries are written as GeoJSON. The zero
+// value writes no bbox and every ordinate with the fewest digits that parse
+// back to the same float64.
+type GeoJSONOptions struct {
+	BBox      bool // Add a bbox member to the outermost object
+	Fixed     bool // Round ordinates to Precision decimal places, dropping trailing zeros
+	Precision int
+
+	// Linearize controls how curves are approximated by straight segments.
+	Linearize LinearizeOptions
+}
+
+// MarshalGeoJSON writes g as a GeoJSON geometry object with the default
+// options. See geojson.go for how types GeoJSON lacks are written.
+func MarshalGeoJSON(g Geometry) ([]byte, error) {
+	return GeoJSONOptions{}.MarshalGeometry(g)
+}
+
+// MarshalJSON writes the feature as GeoJSON with the default options.
+func (f Feature) MarshalJSON() ([]byte, error) {
+	return GeoJSONOptions{}.MarshalFeature(&f)
+}
+
+// MarshalJSON writes the collection as GeoJSON with the default options.
+func (c FeatureCollection) MarshalJSON() ([]byte, error) {
+	return GeoJSONOptions{}.MarshalFeatureCollection(&c)
+}
+
+// MarshalGeometry writes g as a GeoJSON geometry object.
+func (o GeoJSONOptions) MarshalGeometry(g Geometry) ([]byte, error) {
+
+	if g == nil {
+		return nil, errors.New("wktparse: cannot marshal a nil geometry")
+	}
+
+	w := &geojsonWriter{options: o}
+	w.geometry(o.convert(g), true)
+
+	return w.bytes()
+}
+
+// MarshalFeature writes f as a GeoJSON Feature.
+func (o GeoJSONOptions) MarshalFeature(f *Feature) ([]byte, error) {
+	w := &geojsonWriter{options: o}
+	w.feature(f, true)
+	return w.bytes()
+}
+
+// MarshalFeatureCollection writes c as a GeoJSON FeatureCollection. If the
+// bbox option is set it covers every feature in the collection.
+func (o GeoJSONOptions) MarshalFeatureCollection(c *FeatureCollection) ([]byte, error) {
+
+	w := &geojsonWriter{options: o}
+
+	geometries := make([]Geometry, len(c.Features))
+	bounds := emptyBounds()
+	hasZ := false
+	for i, f := range c.Features {
+		if f != nil && f.Geometry != nil {
+			geometries[i] = o.convert(f.Geometry)
+			bounds = bounds.union(geometries[i].Bounds())
+			hasZ = hasZ || geometries[i].Layout().HasZ()
+		}
+	}
+
+	w.WriteString(`{"type":"FeatureCollection"`)
+	if o.BBox {
+		w.bbox(bounds, hasZ)
+	}
+	w.WriteString(`,"features":[`)
+	for i, f := range c.Features {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		if f == nil {
+			w.err = fmt.Errorf("wktparse: feature %d is nil", i)
+			break
+		}
+		w.featureWith(f, geometries[i], false)
+	}
+	w.WriteString(`]}`)
+
+	return w.bytes()
+}
+
+// convert turns g into a geometry made only of types GeoJSON has.
+func (o GeoJSONOptions) convert(g Geometry) Geometry {
+
+	switch g := g.(type) {
+	case *Triangle:
+		polygon := NewPolygon(g.Layout(), g.Rings)
+		polygon.SetSRID(g.SRID())
+		return polygon
+
+	case *TIN:
+		multipolygon := NewMultiPolygon(g.Layout(), nil)
+		for _, triangle := range g.Triangles {
+			multipolygon.Polygons = append(multipolygon.Polygons, NewPolygon(triangle.Layout(), triangle.Rings))
+		}
+		multipolygon.SetSRID(g.SRID())
+		return multipolygon
+
+	case *PolyhedralSurface:
+		multipolygon := NewMultiPolygon(g.Layout(), g.Polygons)
+		multipolygon.SetSRID(g.SRID())
+		return multipolygon
+
+	case *GeometryCollection:
+		collection := NewGeometryCollection(g.Layout(), nil)
+		for _, child := range g.Geometries {
+			collection.Geometries = append(collection.Geometries, o.convert(child))
+		}
+		collection.SetSRID(g.SRID())
+		return collection
+	}
+
+	return Linearize(g, o.Linearize)
+}
+
+type geojsonWriter struct {
+	strings.Builder
+	options GeoJSONOptions
+	err     error
+}
+
+func (w *geojsonWriter) bytes() ([]byte, error) {
+	if w.err != nil {
+		return nil, w.err
+	}
+	return []byte(w.String()), nil
+}
+
+// value writes v with encoding/json, for ids and properties.
+func (w *geojsonWriter) value(v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	w.Write(data)
+}
+
+func (w *geojsonWriter) feature(f *Feature, outer bool) {
+	if f == nil {
+		w.err = errors.New("wktparse: cannot marshal a nil feature")
+		return
+	}
+	var g Geometry
+	if f.Geometry != nil {
+		g = w.options.convert(f.Geometry)
+	}
+	w.featureWith(f, g, outer)
+}
+
+// featureWith writes f with g, its geometry already converted, in place of
+// f.Geometry.
+func (w *geojsonWriter) featureWith(f *Feature, g Geometry, outer bool) {
+
+	w.WriteString(`{"type":"Feature"`)
+
+	if f.ID != nil {
+		w.WriteString(`,"id":`)
+		w.value(f.ID)
+	}
+
+	if outer && w.options.BBox && g != nil {
+		w.bbox(g.Bounds(), g.Layout().HasZ())
+	}
+
+	w.WriteString(`,"geometry":`)
+	if g == nil {
+		w.WriteString("null")
+	} else {
+		w.geometry(g, false)
+	}
+
+	// RFC 7946 requires the member, even if it is null.
+	w.WriteString(`,"properties":`)
+	if f.Properties == nil {
+		w.WriteString("null")
+	} else {
+		w.value(f.Properties)
+	}
+
+	w.WriteByte('}')
+}
+
+// geometry writes a geometry that convert has already reduced to GeoJSON
+// types.
+func (w *geojsonWriter) geometry(g Geometry, outer bool) {
+
+	name := geojsonTypes[g.Type()]
+	if name == "" {
+		if w.err == nil {
+			w.err = fmt.Errorf("wktparse: cannot write %s as GeoJSON", g.Type())
+		}
+		w.WriteString("null")
+		return
+	}
+
+	w.WriteString(`{"type":"`)
+	w.WriteString(name)
+	w.WriteByte('"')
+
+	if outer && w.options.BBox {
+		w.bbox(g.Bounds(), g.Layout().HasZ())
+	}
+
+	if collection, ok := g.(*GeometryCollection); ok {
+		w.WriteString(`,"geometries":[`)
+		for i, child := range collection.Geometries {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.geometry(child, false)
+		}
+		w.WriteString("]}")
+		return
+	}
+
+	w.WriteString(`,"coordinates":`)
+
+	hasZ := g.Layout().HasZ()
+
+	switch g := g.(type) {
+	case *Point:
+		if g.IsEmpty() {
+			w.WriteString("[]")
+		} else {
+			w.position(g.Coordinate, hasZ)
+		}
+
+	case *LineString:
+		w.positions(g.Coordinates, hasZ)
+
+	case *Polygon:
+		w.rings(g.Rings, hasZ)
+
+	case *MultiPoint:
+		w.WriteByte('[')
+		n := 0
+		for _, point := range g.Points {
+			if point.IsEmpty() {
+				continue
+			}
+			if n > 0 {
+				w.WriteByte(',')
+			}
+			w.position(point.Coordinate, hasZ)
+			n++
+		}
+		w.WriteByte(']')
+
+	case *MultiLineString:
+		w.WriteByte('[')
+		for i, line := range g.LineStrings {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.positions(line.Coordinates, hasZ)
+		}
+		w.WriteByte(']')
+
+	case *MultiPolygon:
+		w.WriteByte('[')
+		for i, polygon := range g.Polygons {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.rings(polygon.Rings, hasZ)
+		}
+		w.WriteByte(']')
+	}
+
+	w.WriteByte('}')
+}
+
+var geojsonTypes = map[string]string{
+	"POINT":              "Point",
+	"LINESTRING":         "LineString",
+	"POLYGON":            "Polygon",
+	"MULTIPOINT":         "MultiPoint",
+	"MULTILINESTRING":    "MultiLineString",
+	"MULTIPOLYGON":       "MultiPolygon",
+	"GEOMETRYCOLLECTION": "GeometryCollection",
+}
+
+// rings writes the rings of a polygon following the right-hand rule of RFC
+// 7946: the shell anticlockwise and the holes clockwise. Rings that wind the
+// other way are written in reverse.
+func (w *geojsonWriter) rings(rings [][]Coordinate, hasZ bool) {
+	w.WriteByte('[')
+	for i, ring := range rings {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		area := signedArea(ring)
+		if (i == 0 && area < 0) || (i > 0 && area > 0) {
+			w.reversed(ring, hasZ)
+		} else {
+			w.positions(ring, hasZ)
+		}
+	}
+	w.WriteByte(']')
+}
+
+func (w *geojsonWriter) positions(coordinates []Coordinate, hasZ bool) {
+	w.WriteByte('[')
+	for i, c := range coordinates {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		w.position(c, hasZ)
+	}
+	w.WriteByte(']')
+}
+
+func (w *geojsonWriter) reversed(coordinates []Coordinate, hasZ bool) {
+	w.WriteByte('[')
+	for i := len(coordinates) - 1; i >= 0; i-- {
+		if i < len(coordinates)-1 {
+			w.WriteByte(',')
+		}
+		w.position(coordinates[i], hasZ)
+	}
+	w.WriteByte(']')
+}
+
+func (w *geojsonWriter) position(c Coordinate, hasZ bool) {
+	w.WriteByte('[')
+	w.number(c.X)
+	w.WriteByte(',')
+	w.number(c.Y)
+	if hasZ {
+		w.WriteByte(',')
+		w.number(c.Z)
+	}
+	w.WriteByte(']')
+}
+
+// bbox writes the bbox member: the minimum of each axis followed by the
+// maximum. Empty bounds are left out.
+func (w *geojsonWriter) bbox(b Bounds, hasZ bool) {
+
+	if b.IsEmpty() {
+		return
+	}
+
+	w.WriteString(`,"bbox":[`)
+	w.number(b.Min.X)
+	w.WriteByte(',')
+	w.number(b.Min.Y)
+	if hasZ {
+		w.WriteByte(',')
+		w.number(b.Min.Z)
+	}
+	w.WriteByte(',')
+	w.number(b.Max.X)
+	w.WriteByte(',')
+	w.number(b.Max.Y)
+	if hasZ {
+		w.WriteByte(',')
+		w.number(b.Max.Z)
+	}
+	w.WriteByte(']')
+}
+
+func (w *geojsonWriter) number(v float64) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		if w.err == nil {
+			w.err = fmt.Errorf("wktparse: cannot write %v in GeoJSON", v)
+		}
+		w.WriteString("null")
+		return
+	}
+	w.WriteString(formatNumber(v, w.options.Fixed, w.options.Precision))
+}
+
+// signedArea returns twice the area enclosed by a ring, positive if it
+// winds anticlockwise.
+func signedArea(ring []Coordinate) float64 {
+	area := 0.0
+	for i := 0; i+1 < len(ring); i++ {
+		area += ring[i].X*ring[i+1].Y - ring[i+1].X*ring[i].Y
+	}
+	return area
+}
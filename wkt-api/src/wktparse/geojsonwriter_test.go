@@ -0,0 +1,217 @@
+package wktparse
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestMarshalGeoJSON(t *testing.T) {
+
+	tests := map[string]string{
+		"POINT (1 2)":                           `{"type":"Point","coordinates":[1,2]}`,
+		"POINT Z (1 2 3)":                       `{"type":"Point","coordinates":[1,2,3]}`,
+		"POINT M (1 2 3)":                       `{"type":"Point","coordinates":[1,2]}`,
+		"POINT ZM (1 2 3 4)":                    `{"type":"Point","coordinates":[1,2,3]}`,
+		"POINT EMPTY":                           `{"type":"Point","coordinates":[]}`,
+		"LINESTRING (30 10, 10 30)":             `{"type":"LineString","coordinates":[[30,10],[10,30]]}`,
+		"MULTIPOINT ((1 2), EMPTY)":             `{"type":"MultiPoint","coordinates":[[1,2]]}`,
+		"MULTILINESTRING ((1 2, 3 4), EMPTY)":   `{"type":"MultiLineString","coordinates":[[[1,2],[3,4]],[]]}`,
+		"MULTIPOLYGON (((0 0, 1 0, 1 1, 0 0)))": `{"type":"MultiPolygon","coordinates":[[[[0,0],[1,0],[1,1],[0,0]]]]}`,
+		"GEOMETRYCOLLECTION (POINT (4 6), LINESTRING Z (4 6 1, 7 10 2))": `{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[4,6]},{"type":"LineString","coordinates":[[4,6,1],[7,10,2]]}]}`,
+		"SRID=4326;POINT (0.1 -2.5e-7)":                                  `{"type":"Point","coordinates":[0.1,-2.5e-07]}`,
+	}
+
+	for wkt, want := range tests {
+		g, err := Parse(wkt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := MarshalGeoJSON(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Error("Expected ", want, " for ", wkt, " got ", string(got))
+		}
+		if !json.Valid(got) {
+			t.Error("Expected valid JSON for ", wkt, " got ", string(got))
+		}
+	}
+
+}
+
+func TestMarshalGeoJSONWinding(t *testing.T) {
+
+	// A clockwise shell with an anticlockwise hole, both the wrong way round
+	// for RFC 7946.
+	g, err := Parse("POLYGON ((0 0, 0 10, 10 10, 10 0, 0 0), (2 2, 4 2, 4 4, 2 2))")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := MarshalGeoJSON(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"Polygon","coordinates":[[[0,0],[10,0],[10,10],[0,10],[0,0]],[[2,2],[4,4],[4,2],[2,2]]]}`
+	if string(got) != want {
+		t.Error("Expected ", want, " got ", string(got))
+	}
+
+	// Rings already the right way round are left alone.
+	g, err = Parse("POLYGON ((0 0, 10 0, 10 10, 0 10, 0 0), (2 2, 4 4, 4 2, 2 2))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := MarshalGeoJSON(g); string(got) != want {
+		t.Error("Expected ", want, " got ", string(got))
+	}
+
+}
+
+func TestMarshalGeoJSONFallback(t *testing.T) {
+
+	tests := map[string]string{
+		"TRIANGLE ((0 0, 1 0, 1 1, 0 0))":                      `{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]]]}`,
+		"TIN (((0 0, 1 0, 1 1, 0 0)), ((0 0, 1 1, 0 1, 0 0)))": `{"type":"MultiPolygon","coordinates":[[[[0,0],[1,0],[1,1],[0,0]]],[[[0,0],[1,1],[0,1],[0,0]]]]}`,
+		"POLYHEDRALSURFACE (((0 0, 1 0, 1 1, 0 0)))":           `{"type":"MultiPolygon","coordinates":[[[[0,0],[1,0],[1,1],[0,0]]]]}`,
+		"COMPOUNDCURVE ((0 0, 1 1), (1 1, 2 0))":               `{"type":"LineString","coordinates":[[0,0],[1,1],[2,0]]}`,
+	}
+
+	for wkt, want := range tests {
+		g, err := Parse(wkt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := MarshalGeoJSON(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Error("Expected ", want, " for ", wkt, " got ", string(got))
+		}
+	}
+
+	g, err := Parse("MULTISURFACE (CURVEPOLYGON (CIRCULARSTRING (0 0, 4 0, 0 0)), ((10 10, 14 12, 11 10, 10 10)))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := GeoJSONOptions{Linearize: LinearizeOptions{MaxSegmentAngle: math.Pi / 2}}.MarshalGeometry(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded struct {
+		Type        string
+		Coordinates [][][][]float64
+	}
+	if err := json.Unmarshal(got, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Type != "MultiPolygon" || len(decoded.Coordinates) != 2 {
+		t.Fatal("Expected a MultiPolygon with 2 polygons got ", string(got))
+	}
+	// A full circle in quarters.
+	if len(decoded.Coordinates[0][0]) != 5 {
+		t.Error("Expected the circle to have 5 positions got ", decoded.Coordinates[0][0])
+	}
+
+}
+
+func TestMarshalGeoJSONOptions(t *testing.T) {
+
+	g, err := Parse("LINESTRING Z (1.23456 -0.0001 5, 2.75 3 6.5)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := GeoJSONOptions{BBox: true, Fixed: true, Precision: 2}.MarshalGeometry(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"LineString","bbox":[1.23,0,5,2.75,3,6.5],"coordinates":[[1.23,0,5],[2.75,3,6.5]]}`
+	if string(got) != want {
+		t.Error("Expected ", want, " got ", string(got))
+	}
+
+	empty, _ := Parse("LINESTRING EMPTY")
+	if got, _ := (GeoJSONOptions{BBox: true}).MarshalGeometry(empty); string(got) != `{"type":"LineString","coordinates":[]}` {
+		t.Error("Expected no bbox for an empty geometry got ", string(got))
+	}
+
+}
+
+func TestMarshalFeature(t *testing.T) {
+
+	g, err := Parse("POINT (1 2)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	feature := Feature{ID: 7, Geometry: g, Properties: map[string]interface{}{"name": "a", "height": 2.5}}
+	got, err := json.Marshal(feature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"Feature","id":7,"geometry":{"type":"Point","coordinates":[1,2]},"properties":{"height":2.5,"name":"a"}}`
+	if string(got) != want {
+		t.Error("Expected ", want, " got ", string(got))
+	}
+
+	got, err = json.Marshal(&Feature{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"type":"Feature","geometry":null,"properties":null}`; string(got) != want {
+		t.Error("Expected ", want, " got ", string(got))
+	}
+
+}
+
+func TestMarshalFeatureCollection(t *testing.T) {
+
+	a, _ := Parse("POINT (1 2)")
+	b, _ := Parse("LINESTRING (-3 4, 5 6)")
+
+	collection := &FeatureCollection{Features: []*Feature{
+		{ID: "a", Geometry: a},
+		{ID: "b", Geometry: b, Properties: map[string]interface{}{}},
+		{ID: "c"},
+	}}
+
+	got, err := GeoJSONOptions{BBox: true}.MarshalFeatureCollection(collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"FeatureCollection","bbox":[-3,2,5,6],"features":[` +
+		`{"type":"Feature","id":"a","geometry":{"type":"Point","coordinates":[1,2]},"properties":null},` +
+		`{"type":"Feature","id":"b","geometry":{"type":"LineString","coordinates":[[-3,4],[5,6]]},"properties":{}},` +
+		`{"type":"Feature","id":"c","geometry":null,"properties":null}]}`
+	if string(got) != want {
+		t.Error("Expected ", want, " got ", string(got))
+	}
+
+	got, err = json.Marshal(FeatureCollection{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"type":"FeatureCollection","features":[]}`; string(got) != want {
+		t.Error("Expected ", want, " got ", string(got))
+	}
+
+}
+
+func TestMarshalGeoJSONErrors(t *testing.T) {
+
+	if _, err := MarshalGeoJSON(nil); err == nil {
+		t.Error("Expected an error for a nil geometry")
+	}
+	if _, err := MarshalGeoJSON(NewPoint(XY, Coordinate{X: math.Inf(1), Y: 0})); err == nil {
+		t.Error("Expected an error for an infinite ordinate")
+	}
+	if _, err := (GeoJSONOptions{}).MarshalFeatureCollection(&FeatureCollection{Features: []*Feature{nil}}); err == nil {
+		t.Error("Expected an error for a nil feature")
+	}
+
+}
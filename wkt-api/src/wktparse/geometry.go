@@ -0,0 +1,490 @@
+package wktparse
+
+import (
+	"math"
+)
+
+// Geometry is implemented by every geometry type in this package, so a type
+// switch over the concrete types is enough to handle anything Parse returns.
+type Geometry interface {
+	// Type returns the WKT name of the geometry type without a dimension
+	// tag, e.g. "MULTIPOLYGON".
+	Type() string
+	Layout() Layout
+	// SRID returns the spatial reference system identifier of the geometry,
+	// or 0 if it has none.
+	SRID() int
+	SetSRID(srid int)
+	// IsEmpty reports whether the geometry has no coordinates at all.
+	IsEmpty() bool
+	Bounds() Bounds
+}
+
+// Curve is implemented by the geometries that can make up a curve: a
+// LineString, CircularString or CompoundCurve.
+type Curve interface {
+	Geometry
+	curve()
+}
+
+// Surface is implemented by the geometries that can be members of a
+// MultiSurface: a Polygon or CurvePolygon.
+type Surface interface {
+	Geometry
+	surface()
+}
+
+// header holds what every geometry has apart from its coordinates.
+type header struct {
+	layout Layout
+	srid   int
+}
+
+func (h *header) Layout() Layout {
+	return h.layout
+}
+
+func (h *header) SRID() int {
+	return h.srid
+}
+
+func (h *header) SetSRID(srid int) {
+	h.srid = srid
+}
+
+func (h *header) setLayout(layout Layout) {
+	h.layout = layout
+}
+
+// Bounds is the bounding box of a geometry. Z and M ranges are only
+// meaningful if the geometry's layout has them.
+type Bounds struct {
+	Min Coordinate
+	Max Coordinate
+}
+
+func emptyBounds() Bounds {
+	inf := math.Inf(1)
+	return Bounds{
+		Min: Coordinate{X: inf, Y: inf, Z: inf, M: inf},
+		Max: Coordinate{X: -inf, Y: -inf, Z: -inf, M: -inf},
+	}
+}
+
+// IsEmpty reports whether the bounds contain no coordinates.
+func (b Bounds) IsEmpty() bool {
+	return b.Min.X > b.Max.X
+}
+
+func (b Bounds) extend(c Coordinate) Bounds {
+	b.Min = Coordinate{X: math.Min(b.Min.X, c.X), Y: math.Min(b.Min.Y, c.Y), Z: math.Min(b.Min.Z, c.Z), M: math.Min(b.Min.M, c.M)}
+	b.Max = Coordinate{X: math.Max(b.Max.X, c.X), Y: math.Max(b.Max.Y, c.Y), Z: math.Max(b.Max.Z, c.Z), M: math.Max(b.Max.M, c.M)}
+	return b
+}
+
+func (b Bounds) union(other Bounds) Bounds {
+	if other.IsEmpty() {
+		return b
+	}
+	return b.extend(other.Min).extend(other.Max)
+}
+
+func coordinateBounds(coordinates []Coordinate) Bounds {
+	b := emptyBounds()
+	for _, c := range coordinates {
+		b = b.extend(c)
+	}
+	return b
+}
+
+func ringBounds(rings [][]Coordinate) Bounds {
+	b := emptyBounds()
+	for _, ring := range rings {
+		b = b.union(coordinateBounds(ring))
+	}
+	return b
+}
+
+// Point is a single coordinate, or POINT EMPTY.
+type Point struct {
+	header
+	Coordinate
+	empty bool
+}
+
+func NewPoint(layout Layout, coordinate Coordinate) *Point {
+	return &Point{header: header{layout: layout}, Coordinate: coordinate}
+}
+
+func NewEmptyPoint(layout Layout) *Point {
+	return &Point{header: header{layout: layout}, empty: true}
+}
+
+func (p *Point) Type() string  { return "POINT" }
+func (p *Point) IsEmpty() bool { return p.empty }
+
+func (p *Point) Bounds() Bounds {
+	if p.empty {
+		return emptyBounds()
+	}
+	return emptyBounds().extend(p.Coordinate)
+}
+
+type LineString struct {
+	header
+	Coordinates []Coordinate
+}
+
+func NewLineString(layout Layout, coordinates []Coordinate) *LineString {
+	return &LineString{header: header{layout: layout}, Coordinates: coordinates}
+}
+
+func (l *LineString) Type() string   { return "LINESTRING" }
+func (l *LineString) IsEmpty() bool  { return len(l.Coordinates) == 0 }
+func (l *LineString) Bounds() Bounds { return coordinateBounds(l.Coordinates) }
+func (l *LineString) curve()         {}
+
+// Polygon holds its rings in order, the shell followed by any holes.
+type Polygon struct {
+	header
+	Rings [][]Coordinate
+}
+
+func NewPolygon(layout Layout, rings [][]Coordinate) *Polygon {
+	return &Polygon{header: header{layout: layout}, Rings: rings}
+}
+
+func (p *Polygon) Type() string   { return "POLYGON" }
+func (p *Polygon) IsEmpty() bool  { return len(p.Rings) == 0 }
+func (p *Polygon) Bounds() Bounds { return ringBounds(p.Rings) }
+func (p *Polygon) surface()       {}
+
+// Shell returns the outer ring of the polygon, or nil if it is empty.
+func (p *Polygon) Shell() []Coordinate {
+	if len(p.Rings) == 0 {
+		return nil
+	}
+	return p.Rings[0]
+}
+
+// Holes returns the inner rings of the polygon.
+func (p *Polygon) Holes() [][]Coordinate {
+	if len(p.Rings) < 2 {
+		return nil
+	}
+	return p.Rings[1:]
+}
+
+// Triangle is a polygon with a single ring of four points, the last of
+// which closes the ring.
+type Triangle struct {
+	header
+	Rings [][]Coordinate
+}
+
+func NewTriangle(layout Layout, rings [][]Coordinate) *Triangle {
+	return &Triangle{header: header{layout: layout}, Rings: rings}
+}
+
+func (t *Triangle) Type() string   { return "TRIANGLE" }
+func (t *Triangle) IsEmpty() bool  { return len(t.Rings) == 0 }
+func (t *Triangle) Bounds() Bounds { return ringBounds(t.Rings) }
+
+type MultiPoint struct {
+	header
+	Points []*Point
+}
+
+func NewMultiPoint(layout Layout, points []*Point) *MultiPoint {
+	return &MultiPoint{header: header{layout: layout}, Points: points}
+}
+
+func (m *MultiPoint) Type() string { return "MULTIPOINT" }
+
+func (m *MultiPoint) IsEmpty() bool {
+	for _, g := range m.Points {
+		if !g.IsEmpty() {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *MultiPoint) Bounds() Bounds {
+	b := emptyBounds()
+	for _, g := range m.Points {
+		b = b.union(g.Bounds())
+	}
+	return b
+}
+
+type MultiLineString struct {
+	header
+	LineStrings []*LineString
+}
+
+func NewMultiLineString(layout Layout, lines []*LineString) *MultiLineString {
+	return &MultiLineString{header: header{layout: layout}, LineStrings: lines}
+}
+
+func (m *MultiLineString) Type() string { return "MULTILINESTRING" }
+
+func (m *MultiLineString) IsEmpty() bool {
+	for _, g := range m.LineStrings {
+		if !g.IsEmpty() {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *MultiLineString) Bounds() Bounds {
+	b := emptyBounds()
+	for _, g := range m.LineStrings {
+		b = b.union(g.Bounds())
+	}
+	return b
+}
+
+type MultiPolygon struct {
+	header
+	Polygons []*Polygon
+}
+
+func NewMultiPolygon(layout Layout, polygons []*Polygon) *MultiPolygon {
+	return &MultiPolygon{header: header{layout: layout}, Polygons: polygons}
+}
+
+func (m *MultiPolygon) Type() string { return "MULTIPOLYGON" }
+
+func (m *MultiPolygon) IsEmpty() bool {
+	for _, g := range m.Polygons {
+		if !g.IsEmpty() {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *MultiPolygon) Bounds() Bounds {
+	b := emptyBounds()
+	for _, g := range m.Polygons {
+		b = b.union(g.Bounds())
+	}
+	return b
+}
+
+// PolyhedralSurface is a set of polygon faces that share edges.
+type PolyhedralSurface struct {
+	header
+	Polygons []*Polygon
+}
+
+func NewPolyhedralSurface(layout Layout, polygons []*Polygon) *PolyhedralSurface {
+	return &PolyhedralSurface{header: header{layout: layout}, Polygons: polygons}
+}
+
+func (s *PolyhedralSurface) Type() string { return "POLYHEDRALSURFACE" }
+
+func (s *PolyhedralSurface) IsEmpty() bool {
+	for _, g := range s.Polygons {
+		if !g.IsEmpty() {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *PolyhedralSurface) Bounds() Bounds {
+	b := emptyBounds()
+	for _, g := range s.Polygons {
+		b = b.union(g.Bounds())
+	}
+	return b
+}
+
+// TIN is a triangulated irregular network, a polyhedral surface made only
+// of triangles.
+type TIN struct {
+	header
+	Triangles []*Triangle
+}
+
+func NewTIN(layout Layout, triangles []*Triangle) *TIN {
+	return &TIN{header: header{layout: layout}, Triangles: triangles}
+}
+
+func (t *TIN) Type() string { return "TIN" }
+
+func (t *TIN) IsEmpty() bool {
+	for _, g := range t.Triangles {
+		if !g.IsEmpty() {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *TIN) Bounds() Bounds {
+	b := emptyBounds()
+	for _, g := range t.Triangles {
+		b = b.union(g.Bounds())
+	}
+	return b
+}
+
+// GeometryCollection holds any geometries, including other collections.
+// Each member has its own layout, which need not match the collection's.
+type GeometryCollection struct {
+	header
+	Geometries []Geometry
+}
+
+func NewGeometryCollection(layout Layout, geometries []Geometry) *GeometryCollection {
+	return &GeometryCollection{header: header{layout: layout}, Geometries: geometries}
+}
+
+func (c *GeometryCollection) Type() string { return "GEOMETRYCOLLECTION" }
+
+func (c *GeometryCollection) IsEmpty() bool {
+	for _, g := range c.Geometries {
+		if !g.IsEmpty() {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *GeometryCollection) Bounds() Bounds {
+	b := emptyBounds()
+	for _, g := range c.Geometries {
+		b = b.union(g.Bounds())
+	}
+	return b
+}
+
+// CircularString holds the control points of a series of arcs. Points 0, 1
+// and 2 describe the first arc, points 2, 3 and 4 the second and so on.
+type CircularString struct {
+	header
+	Coordinates []Coordinate
+}
+
+func NewCircularString(layout Layout, coordinates []Coordinate) *CircularString {
+	return &CircularString{header: header{layout: layout}, Coordinates: coordinates}
+}
+
+func (c *CircularString) Type() string   { return "CIRCULARSTRING" }
+func (c *CircularString) IsEmpty() bool  { return len(c.Coordinates) == 0 }
+func (c *CircularString) Bounds() Bounds { return arcsBounds(c.Coordinates) }
+func (c *CircularString) curve()         {}
+
+// CompoundCurve is a chain of LineString and CircularString segments, each
+// starting where the last one ended.
+type CompoundCurve struct {
+	header
+	Segments []Curve
+}
+
+func NewCompoundCurve(layout Layout, segments []Curve) *CompoundCurve {
+	return &CompoundCurve{header: header{layout: layout}, Segments: segments}
+}
+
+func (c *CompoundCurve) Type() string { return "COMPOUNDCURVE" }
+
+func (c *CompoundCurve) IsEmpty() bool {
+	for _, g := range c.Segments {
+		if !g.IsEmpty() {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *CompoundCurve) Bounds() Bounds {
+	b := emptyBounds()
+	for _, g := range c.Segments {
+		b = b.union(g.Bounds())
+	}
+	return b
+}
+func (c *CompoundCurve) curve() {}
+
+// CurvePolygon is a polygon whose rings may be curves. The first ring is
+// the shell.
+type CurvePolygon struct {
+	header
+	Rings []Curve
+}
+
+func NewCurvePolygon(layout Layout, rings []Curve) *CurvePolygon {
+	return &CurvePolygon{header: header{layout: layout}, Rings: rings}
+}
+
+func (c *CurvePolygon) Type() string  { return "CURVEPOLYGON" }
+func (c *CurvePolygon) IsEmpty() bool { return len(c.Rings) == 0 }
+func (c *CurvePolygon) surface()      {}
+
+func (c *CurvePolygon) Bounds() Bounds {
+	b := emptyBounds()
+	for _, ring := range c.Rings {
+		b = b.union(ring.Bounds())
+	}
+	return b
+}
+
+type MultiCurve struct {
+	header
+	Curves []Curve
+}
+
+func NewMultiCurve(layout Layout, curves []Curve) *MultiCurve {
+	return &MultiCurve{header: header{layout: layout}, Curves: curves}
+}
+
+func (m *MultiCurve) Type() string { return "MULTICURVE" }
+
+func (m *MultiCurve) IsEmpty() bool {
+	for _, g := range m.Curves {
+		if !g.IsEmpty() {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *MultiCurve) Bounds() Bounds {
+	b := emptyBounds()
+	for _, g := range m.Curves {
+		b = b.union(g.Bounds())
+	}
+	return b
+}
+
+type MultiSurface struct {
+	header
+	Surfaces []Surface
+}
+
+func NewMultiSurface(layout Layout, surfaces []Surface) *MultiSurface {
+	return &MultiSurface{header: header{layout: layout}, Surfaces: surfaces}
+}
+
+func (m *MultiSurface) Type() string { return "MULTISURFACE" }
+
+func (m *MultiSurface) IsEmpty() bool {
+	for _, g := range m.Surfaces {
+		if !g.IsEmpty() {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *MultiSurface) Bounds() Bounds {
+	b := emptyBounds()
+	for _, g := range m.Surfaces {
+		b = b.union(g.Bounds())
+	}
+	return b
+}
@@ -0,0 +1,122 @@
+package wktparse
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseTypes(t *testing.T) {
+
+	tests := map[string]string{
+		"POINT (1 2)":                                "*wktparse.Point",
+		"LINESTRING (1 2, 3 4)":                      "*wktparse.LineString",
+		"POLYGON ((0 0, 1 0, 1 1, 0 0))":             "*wktparse.Polygon",
+		"MULTIPOINT (1 2, 3 4)":                      "*wktparse.MultiPoint",
+		"MULTILINESTRING ((1 2, 3 4))":               "*wktparse.MultiLineString",
+		"MULTIPOLYGON (((0 0, 1 0, 1 1, 0 0)))":      "*wktparse.MultiPolygon",
+		"TRIANGLE ((0 0, 1 0, 1 1, 0 0))":            "*wktparse.Triangle",
+		"TIN (((0 0, 1 0, 1 1, 0 0)))":               "*wktparse.TIN",
+		"POLYHEDRALSURFACE (((0 0, 1 0, 1 1, 0 0)))": "*wktparse.PolyhedralSurface",
+		"GEOMETRYCOLLECTION (POINT (1 2))":           "*wktparse.GeometryCollection",
+		"CIRCULARSTRING (0 0, 1 1, 2 0)":             "*wktparse.CircularString",
+		"COMPOUNDCURVE ((0 0, 1 1))":                 "*wktparse.CompoundCurve",
+		"CURVEPOLYGON ((0 0, 1 0, 1 1, 0 0))":        "*wktparse.CurvePolygon",
+		"MULTICURVE ((0 0, 1 1))":                    "*wktparse.MultiCurve",
+		"MULTISURFACE (((0 0, 1 0, 1 1, 0 0)))":      "*wktparse.MultiSurface",
+	}
+
+	for wkt, want := range tests {
+		g, err := Parse(wkt)
+		if err != nil {
+			t.Error(wkt, ": ", err)
+			continue
+		}
+		if got := fmt.Sprintf("%T", g); got != want {
+			t.Error("Expected ", want, " for ", wkt, " got ", got)
+		}
+	}
+
+}
+
+func TestParseLayout(t *testing.T) {
+
+	tests := map[string]Layout{
+		"POINT (1 2)":        XY,
+		"POINT Z (1 2 3)":    XYZ,
+		"POINT M (1 2 3)":    XYM,
+		"POINT ZM (1 2 3 4)": XYZM,
+		"POINT (1 2 3)":      XYZ,
+		"POINT (1 2 3 4)":    XYZM,
+	}
+
+	for wkt, want := range tests {
+		g, err := Parse(wkt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.Layout() != want {
+			t.Error("Expected ", want, " for ", wkt, " got ", g.Layout())
+		}
+	}
+
+	// The layout is only known once the first coordinate is read, and every
+	// part ends up with the same one.
+	g, err := Parse("MULTILINESTRING (EMPTY, (1 2 3, 4 5 6))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	multiline := g.(*MultiLineString)
+	if multiline.Layout() != XYZ {
+		t.Error("Expected XYZ got ", multiline.Layout())
+	}
+	for i, line := range multiline.LineStrings {
+		if line.Layout() != XYZ {
+			t.Error("Expected part ", i, " to be XYZ got ", line.Layout())
+		}
+	}
+
+}
+
+func TestIsEmpty(t *testing.T) {
+
+	tests := map[string]bool{
+		"POINT EMPTY":                      true,
+		"POINT (1 2)":                      false,
+		"LINESTRING EMPTY":                 true,
+		"MULTIPOINT (EMPTY)":               true,
+		"MULTIPOINT (EMPTY, (1 2))":        false,
+		"GEOMETRYCOLLECTION (POINT EMPTY)": true,
+		"GEOMETRYCOLLECTION (POINT (1 2))": false,
+		"MULTIPOLYGON EMPTY":               true,
+	}
+
+	for wkt, want := range tests {
+		g, err := Parse(wkt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.IsEmpty() != want {
+			t.Error("Expected IsEmpty ", want, " for ", wkt)
+		}
+	}
+
+}
+
+func TestBounds(t *testing.T) {
+
+	g, err := Parse("GEOMETRYCOLLECTION (POINT (-1 5), LINESTRING (2 -3, 4 0), POLYGON EMPTY)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bounds := g.Bounds()
+	if bounds.Min != (Coordinate{X: -1, Y: -3}) || bounds.Max != (Coordinate{X: 4, Y: 5}) {
+		t.Error("Expected bounds (-1 -3, 4 5) got ", bounds)
+	}
+
+	empty, _ := Parse("POINT EMPTY")
+	if !empty.Bounds().IsEmpty() {
+		t.Error("Expected empty bounds for POINT EMPTY got ", empty.Bounds())
+	}
+
+}
@@ -0,0 +1,288 @@
+package wktparse
+
+import (
+	"fmt"
+)
+
+// Layout says which ordinates the coordinates of a geometry have.
+type Layout int
+
+const (
+	XY Layout = iota
+	XYZ
+	XYM
+	XYZM
+)
+
+var layoutNames = [...]string{"XY", "XYZ", "XYM", "XYZM"}
+var layoutTags = [...]string{"", "Z", "M", "ZM"}
+
+func (l Layout) String() string {
+	if l < XY || l > XYZM {
+		return "Layout(?)"
+	}
+	return layoutNames[l]
+}
+
+// Tag returns the dimension tag WKT uses for the layout: "", "Z", "M" or "ZM".
+func (l Layout) Tag() string {
+	if l < XY || l > XYZM {
+		return ""
+	}
+	return layoutTags[l]
+}
+
+// Stride returns the number of ordinates in each coordinate.
+func (l Layout) Stride() int {
+	switch l {
+	case XYZ, XYM:
+		return 3
+	case XYZM:
+		return 4
+	}
+	return 2
+}
+
+func layoutFromTag(tag string) Layout {
+	switch tag {
+	case "Z":
+		return XYZ
+	case "M":
+		return XYM
+	case "ZM":
+		return XYZM
+	}
+	return XY
+}
+
+// HasZ reports whether coordinates in this layout have a Z ordinate.
+func (l Layout) HasZ() bool {
+	return l == XYZ || l == XYZM
+}
+
+// HasM reports whether coordinates in this layout have an M ordinate.
+func (l Layout) HasM() bool {
+	return l == XYM || l == XYZM
+}
+
+// MarshalText writes the layout as its name, e.g. "XYZ".
+func (l Layout) MarshalText() ([]byte, error) {
+	if l < XY || l > XYZM {
+		return nil, fmt.Errorf("wktparse: invalid layout %d", int(l))
+	}
+	return []byte(layoutNames[l]), nil
+}
+
+// UnmarshalText reads a layout name written by MarshalText.
+func (l *Layout) UnmarshalText(text []byte) error {
+	for i, name := range layoutNames {
+		if string(text) == name {
+			*l = Layout(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("wktparse: unknown layout %q", text)
+}
+
+// Ordinates returns the ordinates of c that exist in the layout, in WKT
+// order: X, Y, then Z and M if present.
+func (l Layout) Ordinates(c Coordinate) []float64 {
+	switch l {
+	case XYZ:
+		return []float64{c.X, c.Y, c.Z}
+	case XYM:
+		return []float64{c.X, c.Y, c.M}
+	case XYZM:
+		return []float64{c.X, c.Y, c.Z, c.M}
+	}
+	return []float64{c.X, c.Y}
+}
+
+// The conversions below return a new geometry with the same SRID as g and
+// leave g as it was.
+
+// Force2D returns a copy of g with only X and Y ordinates.
+func Force2D(g Geometry) Geometry {
+	out := convert(g, XY, func(c Coordinate) Coordinate {
+		return Coordinate{X: c.X, Y: c.Y}
+	})
+	out.SetSRID(g.SRID())
+	return out
+}
+
+// Force3D returns a copy of g with a Z ordinate. Coordinates that had no Z
+// are given z; existing Z values are kept, and so is M if g has it, so XY
+// becomes XYZ and XYM becomes XYZM.
+func Force3D(g Geometry, z float64) Geometry {
+
+	layout := XYZ
+	if g.Layout().HasM() {
+		layout = XYZM
+	}
+
+	if collection, ok := g.(*GeometryCollection); ok {
+		// Members of an untagged collection can each have their own layout.
+		out := NewGeometryCollection(layout, nil)
+		for _, child := range collection.Geometries {
+			out.Geometries = append(out.Geometries, Force3D(child, z))
+		}
+		out.SetSRID(g.SRID())
+		return out
+	}
+
+	hasZ := g.Layout().HasZ()
+
+	out := convert(g, layout, func(c Coordinate) Coordinate {
+		if !hasZ {
+			c.Z = z
+		}
+		return c
+	})
+	out.SetSRID(g.SRID())
+	return out
+}
+
+// DropM returns a copy of g without M ordinates, so XYM becomes XY and XYZM
+// becomes XYZ.
+func DropM(g Geometry) Geometry {
+
+	layout := XY
+	if g.Layout().HasZ() {
+		layout = XYZ
+	}
+
+	if collection, ok := g.(*GeometryCollection); ok {
+		out := NewGeometryCollection(layout, nil)
+		for _, child := range collection.Geometries {
+			out.Geometries = append(out.Geometries, DropM(child))
+		}
+		out.SetSRID(g.SRID())
+		return out
+	}
+
+	out := convert(g, layout, func(c Coordinate) Coordinate {
+		c.M = 0
+		return c
+	})
+	out.SetSRID(g.SRID())
+	return out
+}
+
+// convert returns a deep copy of g in the given layout with f applied to
+// every coordinate.
+func convert(g Geometry, layout Layout, f func(Coordinate) Coordinate) Geometry {
+
+	coordinates := func(in []Coordinate) []Coordinate {
+		if in == nil {
+			return nil
+		}
+		out := make([]Coordinate, len(in))
+		for i, c := range in {
+			out[i] = f(c)
+		}
+		return out
+	}
+
+	rings := func(in [][]Coordinate) [][]Coordinate {
+		if in == nil {
+			return nil
+		}
+		out := make([][]Coordinate, len(in))
+		for i, ring := range in {
+			out[i] = coordinates(ring)
+		}
+		return out
+	}
+
+	switch g := g.(type) {
+	case *Point:
+		if g.IsEmpty() {
+			return NewEmptyPoint(layout)
+		}
+		return NewPoint(layout, f(g.Coordinate))
+
+	case *LineString:
+		return NewLineString(layout, coordinates(g.Coordinates))
+
+	case *CircularString:
+		return NewCircularString(layout, coordinates(g.Coordinates))
+
+	case *Polygon:
+		return NewPolygon(layout, rings(g.Rings))
+
+	case *Triangle:
+		return NewTriangle(layout, rings(g.Rings))
+
+	case *MultiPoint:
+		multipoint := NewMultiPoint(layout, nil)
+		for _, point := range g.Points {
+			multipoint.Points = append(multipoint.Points, convert(point, layout, f).(*Point))
+		}
+		return multipoint
+
+	case *MultiLineString:
+		multiline := NewMultiLineString(layout, nil)
+		for _, line := range g.LineStrings {
+			multiline.LineStrings = append(multiline.LineStrings, NewLineString(layout, coordinates(line.Coordinates)))
+		}
+		return multiline
+
+	case *MultiPolygon:
+		multipolygon := NewMultiPolygon(layout, nil)
+		for _, polygon := range g.Polygons {
+			multipolygon.Polygons = append(multipolygon.Polygons, NewPolygon(layout, rings(polygon.Rings)))
+		}
+		return multipolygon
+
+	case *PolyhedralSurface:
+		surface := NewPolyhedralSurface(layout, nil)
+		for _, polygon := range g.Polygons {
+			surface.Polygons = append(surface.Polygons, NewPolygon(layout, rings(polygon.Rings)))
+		}
+		return surface
+
+	case *TIN:
+		tin := NewTIN(layout, nil)
+		for _, triangle := range g.Triangles {
+			tin.Triangles = append(tin.Triangles, NewTriangle(layout, rings(triangle.Rings)))
+		}
+		return tin
+
+	case *CompoundCurve:
+		compound := NewCompoundCurve(layout, nil)
+		for _, segment := range g.Segments {
+			compound.Segments = append(compound.Segments, convert(segment, layout, f).(Curve))
+		}
+		return compound
+
+	case *CurvePolygon:
+		polygon := NewCurvePolygon(layout, nil)
+		for _, ring := range g.Rings {
+			polygon.Rings = append(polygon.Rings, convert(ring, layout, f).(Curve))
+		}
+		return polygon
+
+	case *MultiCurve:
+		multicurve := NewMultiCurve(layout, nil)
+		for _, curve := range g.Curves {
+			multicurve.Curves = append(multicurve.Curves, convert(curve, layout, f).(Curve))
+		}
+		return multicurve
+
+	case *MultiSurface:
+		multisurface := NewMultiSurface(layout, nil)
+		for _, surface := range g.Surfaces {
+			multisurface.Surfaces = append(multisurface.Surfaces, convert(surface, layout, f).(Surface))
+		}
+		return multisurface
+
+	case *GeometryCollection:
+		collection := NewGeometryCollection(layout, nil)
+		for _, child := range g.Geometries {
+			collection.Geometries = append(collection.Geometries, convert(child, layout, f))
+		}
+		return collection
+	}
+
+	return g
+}
@@ -0,0 +1,159 @@
+package wktparse
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLayoutDistinguishesMFromZM(t *testing.T) {
+
+	m, err := Parse("POINT M (1 2 3)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	zm, err := Parse("POINT ZM (1 2 0 3)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.(*Point).Coordinate != zm.(*Point).Coordinate {
+		t.Fatal("Expected the same coordinate got ", m.(*Point).Coordinate, zm.(*Point).Coordinate)
+	}
+	if m.Layout() != XYM || zm.Layout() != XYZM {
+		t.Error("Expected XYM and XYZM got ", m.Layout(), zm.Layout())
+	}
+
+	if got := XYM.Ordinates(m.(*Point).Coordinate); !reflect.DeepEqual(got, []float64{1, 2, 3}) {
+		t.Error("Expected [1 2 3] got ", got)
+	}
+
+}
+
+func TestCoordinateSetJSON(t *testing.T) {
+
+	tests := map[string]string{
+		"POINT (1 2)":        `{"Layout":"XY","Coordinates":[{"X":1,"Y":2}],"Rings":null,"Parts":null,"Children":null}`,
+		"POINT Z (1 2 0)":    `{"Layout":"XYZ","Coordinates":[{"X":1,"Y":2,"Z":0}],"Rings":null,"Parts":null,"Children":null}`,
+		"POINT M (1 2 3)":    `{"Layout":"XYM","Coordinates":[{"X":1,"Y":2,"M":3}],"Rings":null,"Parts":null,"Children":null}`,
+		"POINT ZM (1 2 0 3)": `{"Layout":"XYZM","Coordinates":[{"X":1,"Y":2,"Z":0,"M":3}],"Rings":null,"Parts":null,"Children":null}`,
+	}
+
+	for wkt, want := range tests {
+		_, set, err := ParseGeometry(wkt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := json.Marshal(set)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Error("Expected ", want, " for ", wkt, " got ", string(got))
+		}
+	}
+
+	_, set, err := ParseGeometry("GEOMETRYCOLLECTION (POLYGON ((0 0, 1 0, 1 1, 0 0)), MULTILINESTRING ((1 2, 3 4)))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(got), `"Z"`) || strings.Contains(string(got), `"M"`) {
+		t.Error("Expected no Z or M in 2D JSON got ", string(got))
+	}
+
+	var decoded CoordinateSet
+	if err := json.Unmarshal(got, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, set) {
+		t.Error("Expected JSON to round trip got ", decoded)
+	}
+
+}
+
+func TestForce2D(t *testing.T) {
+
+	g, err := Parse("LINESTRING ZM (1 2 3 4, 5 6 7 8)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	line := Force2D(g).(*LineString)
+	if line.Layout() != XY {
+		t.Error("Expected XY got ", line.Layout())
+	}
+	if line.Coordinates[1] != (Coordinate{X: 5, Y: 6}) {
+		t.Error("Expected (5 6) got ", line.Coordinates[1])
+	}
+
+	// The original is left alone.
+	if g.(*LineString).Coordinates[1].M != 8 {
+		t.Error("Expected Force2D not to change its argument")
+	}
+
+}
+
+func TestForce3D(t *testing.T) {
+
+	tests := []struct {
+		wkt    string
+		layout Layout
+		want   Coordinate
+	}{
+		{"POINT (1 2)", XYZ, Coordinate{X: 1, Y: 2, Z: 10}},
+		{"POINT Z (1 2 3)", XYZ, Coordinate{X: 1, Y: 2, Z: 3}},
+		{"POINT M (1 2 4)", XYZM, Coordinate{X: 1, Y: 2, Z: 10, M: 4}},
+		{"POINT ZM (1 2 3 4)", XYZM, Coordinate{X: 1, Y: 2, Z: 3, M: 4}},
+	}
+
+	for _, test := range tests {
+		g, err := Parse(test.wkt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		point := Force3D(g, 10).(*Point)
+		if point.Layout() != test.layout || point.Coordinate != test.want {
+			t.Error("Expected ", test.layout, test.want, " for ", test.wkt, " got ", point.Layout(), point.Coordinate)
+		}
+	}
+
+	g, err := Parse("GEOMETRYCOLLECTION (POINT (1 2), POINT M (3 4 5))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	collection := Force3D(g, 0).(*GeometryCollection)
+	if collection.Geometries[0].Layout() != XYZ || collection.Geometries[1].Layout() != XYZM {
+		t.Error("Expected XYZ and XYZM members got ", collection.Geometries[0].Layout(), collection.Geometries[1].Layout())
+	}
+
+}
+
+func TestDropM(t *testing.T) {
+
+	g, err := Parse("MULTIPOLYGON ZM (((0 0 1 2, 1 0 1 2, 1 1 1 2, 0 0 1 2)))")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	multipolygon := DropM(g).(*MultiPolygon)
+	if multipolygon.Layout() != XYZ || multipolygon.Polygons[0].Layout() != XYZ {
+		t.Error("Expected XYZ got ", multipolygon.Layout())
+	}
+	if c := multipolygon.Polygons[0].Shell()[2]; c != (Coordinate{X: 1, Y: 1, Z: 1}) {
+		t.Error("Expected (1 1 1) got ", c)
+	}
+
+	g, err = Parse("CIRCULARSTRING M (0 0 1, 1 1 2, 2 0 3)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if layout := DropM(g).Layout(); layout != XY {
+		t.Error("Expected XY got ", layout)
+	}
+
+}
@@ -0,0 +1,190 @@
+package wktparse
+
+import (
+	"fmt"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokKeyword
+	tokDimension
+	tokEmpty
+	tokNumber
+	tokLParen
+	tokRParen
+	tokComma
+	tokEquals
+	tokSemicolon
+)
+
+func (k tokenKind) String() string {
+	switch k {
+	case tokEOF:
+		return "end of input"
+	case tokKeyword:
+		return "keyword"
+	case tokDimension:
+		return "dimension"
+	case tokEmpty:
+		return "EMPTY"
+	case tokNumber:
+		return "number"
+	case tokLParen:
+		return "'('"
+	case tokRParen:
+		return "')'"
+	case tokComma:
+		return "','"
+	case tokEquals:
+		return "'='"
+	case tokSemicolon:
+		return "';'"
+	}
+	return "unknown token"
+}
+
+// A token is a single lexical element of a WKT string. Text is a slice of
+// the input rather than a copy, and pos is the byte offset of its first
+// character.
+type token struct {
+	kind tokenKind
+	text []byte
+	pos  int
+}
+
+// lexer splits a WKT string into tokens. Keywords are matched without
+// regard to case so the input never has to be uppercased as a whole.
+type lexer struct {
+	input []byte
+	pos   int
+}
+
+func (l *lexer) next() (token, error) {
+
+	for l.pos < len(l.input) && isSpace(l.input[l.pos]) {
+		l.pos++
+	}
+
+	if l.pos >= len(l.input) {
+		return token{kind: tokEOF, pos: l.pos}, nil
+	}
+
+	start := l.pos
+	c := l.input[l.pos]
+
+	switch {
+	case c == '(':
+		l.pos++
+		return token{kind: tokLParen, text: l.input[start:l.pos], pos: start}, nil
+	case c == ')':
+		l.pos++
+		return token{kind: tokRParen, text: l.input[start:l.pos], pos: start}, nil
+	case c == ',':
+		l.pos++
+		return token{kind: tokComma, text: l.input[start:l.pos], pos: start}, nil
+	case c == '=':
+		l.pos++
+		return token{kind: tokEquals, text: l.input[start:l.pos], pos: start}, nil
+	case c == ';':
+		l.pos++
+		return token{kind: tokSemicolon, text: l.input[start:l.pos], pos: start}, nil
+	case isLetter(c):
+		for l.pos < len(l.input) && isLetter(l.input[l.pos]) {
+			l.pos++
+		}
+		text := l.input[start:l.pos]
+		return token{kind: wordKind(text), text: text, pos: start}, nil
+	case isDigit(c) || c == '-' || c == '+' || c == '.':
+		return l.number()
+	}
+
+	return token{}, newParseError(l.input, start, "keyword, number or punctuation", fmt.Sprintf("%q", c))
+}
+
+// number scans a decimal number with an optional sign, fraction and
+// exponent, e.g. -12, 3.5, .5, 1e10 or 6.02E-23.
+func (l *lexer) number() (token, error) {
+
+	start := l.pos
+
+	if l.input[l.pos] == '-' || l.input[l.pos] == '+' {
+		l.pos++
+	}
+
+	digits := l.digits()
+	if l.pos < len(l.input) && l.input[l.pos] == '.' {
+		l.pos++
+		digits += l.digits()
+	}
+
+	if digits == 0 {
+		return token{}, newParseError(l.input, start, "number", fmt.Sprintf("%q", l.input[start:l.pos]))
+	}
+
+	if l.pos < len(l.input) && (l.input[l.pos] == 'e' || l.input[l.pos] == 'E') {
+		l.pos++
+		if l.pos < len(l.input) && (l.input[l.pos] == '-' || l.input[l.pos] == '+') {
+			l.pos++
+		}
+		if l.digits() == 0 {
+			return token{}, newParseError(l.input, start, "number", fmt.Sprintf("%q", l.input[start:l.pos]))
+		}
+	}
+
+	// Numbers must be separated from whatever follows, so 1.2.3 or 1-2 is
+	// an error rather than two numbers.
+	if l.pos < len(l.input) {
+		if c := l.input[l.pos]; c == '.' || c == '-' || c == '+' || isLetter(c) {
+			return token{}, newParseError(l.input, start, "number", fmt.Sprintf("%q", l.input[start:l.pos+1]))
+		}
+	}
+
+	return token{kind: tokNumber, text: l.input[start:l.pos], pos: start}, nil
+}
+
+func (l *lexer) digits() int {
+	n := 0
+	for l.pos < len(l.input) && isDigit(l.input[l.pos]) {
+		l.pos++
+		n++
+	}
+	return n
+}
+
+func wordKind(word []byte) tokenKind {
+	switch {
+	case equalFold(word, "EMPTY"):
+		return tokEmpty
+	case dimension(word) != XY:
+		return tokDimension
+	}
+	return tokKeyword
+}
+
+// dimension returns the layout of a Z, M or ZM tag in any case, or XY if
+// word is not one.
+func dimension(word []byte) Layout {
+	switch {
+	case len(word) == 1 && (word[0] == 'Z' || word[0] == 'z'):
+		return XYZ
+	case len(word) == 1 && (word[0] == 'M' || word[0] == 'm'):
+		return XYM
+	case equalFold(word, "ZM"):
+		return XYZM
+	}
+	return XY
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,98 @@
+package wktparse
+
+import (
+	"strconv"
+)
+
+// Powers of ten that a float64 holds exactly.
+var exactPowersOfTen = [...]float64{
+	1e0, 1e1, 1e2, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8, 1e9, 1e10,
+	1e11, 1e12, 1e13, 1e14, 1e15, 1e16, 1e17, 1e18, 1e19, 1e20,
+	1e21, 1e22,
+}
+
+// parseNumber converts a number token to a float64 without first copying it
+// into a string. Most coordinates have few enough digits that the mantissa
+// and the power of ten are both exact float64 values, and then a single
+// multiplication or division gives the correctly rounded result. Anything
+// else falls back to strconv.ParseFloat. The lexer has already checked the
+// syntax, so this only has to read it.
+func parseNumber(text []byte) (float64, error) {
+
+	i := 0
+	negative := false
+	if i < len(text) && (text[i] == '-' || text[i] == '+') {
+		negative = text[i] == '-'
+		i++
+	}
+
+	var mantissa uint64
+	digits, exponent := 0, 0
+	seenPoint := false
+
+	for ; i < len(text); i++ {
+		c := text[i]
+		if c == '.' {
+			seenPoint = true
+			continue
+		}
+		if !isDigit(c) {
+			break
+		}
+		if mantissa == 0 && c == '0' {
+			// Leading zeros are not significant, but after the point
+			// they still move it.
+			if seenPoint {
+				exponent--
+			}
+			continue
+		}
+		if digits == 19 {
+			return parseFloat(text)
+		}
+		mantissa = mantissa*10 + uint64(c-'0')
+		digits++
+		if seenPoint {
+			exponent--
+		}
+	}
+
+	if i < len(text) {
+		// An exponent, which the lexer has made sure has digits.
+		i++
+		sign := 1
+		if text[i] == '-' || text[i] == '+' {
+			if text[i] == '-' {
+				sign = -1
+			}
+			i++
+		}
+		e := 0
+		for ; i < len(text); i++ {
+			if e < 10000 {
+				e = e*10 + int(text[i]-'0')
+			}
+		}
+		exponent += sign * e
+	}
+
+	f := float64(mantissa)
+	switch {
+	case mantissa == 0:
+	case mantissa > 1<<53 || exponent < -22 || exponent > 22:
+		return parseFloat(text)
+	case exponent < 0:
+		f /= exactPowersOfTen[-exponent]
+	default:
+		f *= exactPowersOfTen[exponent]
+	}
+
+	if negative {
+		f = -f
+	}
+	return f, nil
+}
+
+func parseFloat(text []byte) (float64, error) {
+	return strconv.ParseFloat(string(text), 64)
+}
@@ -0,0 +1,38 @@
+package wktparse
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func TestParseNumber(t *testing.T) {
+
+	numbers := []string{
+		"0", "-0", "+0", "0.0", ".5", "-.5", "5.", "00012.5000", "1e10", "1E-10", "6.02E+23",
+		"-12", "+3.25", "0.1", "0.3", "123456789012345678", "1234567890123456789", "12345678901234567890",
+		"9007199254740993", "0.000000000000000000001", "1e22", "1e23", "1e-22", "1e-23",
+		"179769313486231570000000000000000000000000000000000000000000000000000000000000",
+		"4.9e-324", "1e400", "2.2250738585072014e-308", "-122.41941550000001", "37.77492950000000",
+	}
+
+	// Coordinates of the kind real data has, written with varying precision.
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		v := (r.Float64() - 0.5) * math.Pow(10, float64(r.Intn(16)-4))
+		numbers = append(numbers, strconv.FormatFloat(v, 'f', r.Intn(18)-1, 64), strconv.FormatFloat(v, 'e', -1, 64))
+	}
+
+	for _, number := range numbers {
+		want, wantErr := strconv.ParseFloat(number, 64)
+		got, err := parseNumber([]byte(number))
+		if (err != nil) != (wantErr != nil) {
+			t.Error("Expected error ", wantErr, " for ", number, " got ", err)
+		}
+		if math.Float64bits(got) != math.Float64bits(want) {
+			t.Error("Expected ", want, " for ", number, " got ", got)
+		}
+	}
+
+}
@@ -0,0 +1,809 @@
+package wktparse
+
+// The parser follows the WKT grammar from the OGC Simple Features
+// specification (06-103r4, section 7.2). Each production below is handled
+// by one method of parser:
+//
+//   <extended text>         ::= [ SRID = <integer> ; ] <geometry tagged text>
+//   <geometry tagged text>  ::= <keyword> [ Z | M | ZM ] <geometry text>
+//   <point text>            ::= EMPTY | ( <point> )
+//   <linestring text>       ::= EMPTY | ( <point> {, <point>}* )
+//   <polygon text>          ::= EMPTY | ( <linestring text> {, <linestring text>}* )
+//   <multipoint text>       ::= EMPTY | ( <member> {, <member>}* )
+//   <member>                ::= EMPTY | ( <point> ) | <point>
+//   <multilinestring text>  ::= EMPTY | ( <linestring text> {, <linestring text>}* )
+//   <multipolygon text>     ::= EMPTY | ( <polygon text> {, <polygon text>}* )
+//   <triangle text>         ::= EMPTY | ( <linestring text> )
+//   <tin text>              ::= EMPTY | ( <triangle text> {, <triangle text>}* )
+//   <polyhedral text>       ::= EMPTY | ( <polygon text> {, <polygon text>}* )
+//   <collection text>       ::= EMPTY | ( <geometry tagged text> {, <geometry tagged text>}* )
+//   <circularstring text>   ::= <linestring text>
+//   <compoundcurve text>    ::= EMPTY | ( <curve> {, <curve>}* )
+//   <curvepolygon text>     ::= EMPTY | ( <any curve> {, <any curve>}* )
+//   <multicurve text>       ::= EMPTY | ( <any curve> {, <any curve>}* )
+//   <multisurface text>     ::= EMPTY | ( <surface> {, <surface>}* )
+//   <curve>                 ::= <linestring text> | CIRCULARSTRING <circularstring text>
+//   <any curve>             ::= <curve> | COMPOUNDCURVE <compoundcurve text>
+//   <surface>               ::= <polygon text> | CURVEPOLYGON <curvepolygon text>
+//   <point>                 ::= <x> <y> [ <z> ] [ <m> ]
+//
+// When no dimension tag is given the dimension is taken from the number of
+// ordinates in the first coordinate, so POINT (1 2 3) reads as POINT Z.
+// Members of a GEOMETRYCOLLECTION are tagged geometries in their own right
+// and each has its own dimension, while the members of the curve types share
+// the dimension of the geometry that contains them.
+//
+//
+// Each ring of a polygon must be closed, ending at the point it starts at,
+// and so have at least four points.
+// The SRID prefix is the Extended WKT (EWKT) that PostGIS writes. PostGIS
+// also writes M geometries with the tag run into the keyword, as POINTM, and
+// that spelling is read the same as POINT M.
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type parser struct {
+	lex lexer
+	tok token
+
+	// Layout of the geometry currently being parsed and whether it has been
+	// fixed yet, either by a dimension tag or by the first coordinate.
+	layout      Layout
+	layoutKnown bool
+
+	// Number of GEOMETRYCOLLECTIONs the current geometry is inside.
+	depth int
+
+	// Storage shared by every coordinate list and ring list of a parse. Each
+	// list is a full slice expression over the part it used, so appending to
+	// one cannot overwrite the next.
+	coordinates []Coordinate
+	rings       [][]Coordinate
+}
+
+// Nesting limit for collections, so that a small, malicious input cannot
+// recurse without bound.
+const maxWKTDepth = 128
+
+// parse reads exactly one tagged geometry and requires that nothing but
+// whitespace follows it. If want is not empty the geometry must be of that
+// type, ignoring the dimension tag.
+func parse(input []byte, want string) (Geometry, error) {
+	var p parser
+	return p.parse(input, want)
+}
+
+// parse is the package level parse, but reuses the storage p has left over
+// from any earlier parse, overwriting the geometry that parse returned.
+func (p *parser) parse(input []byte, want string) (Geometry, error) {
+
+	p.lex = lexer{input: input}
+	p.coordinates, p.rings = p.coordinates[:0], p.rings[:0]
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	srid, err := p.srid()
+	if err != nil {
+		return nil, err
+	}
+
+	if want != "" {
+		name, _ := geometryName(p.tok.text)
+		if p.tok.kind != tokKeyword || name != strings.ToUpper(want) {
+			return nil, p.errorf(p.tok.pos, want, p.found())
+		}
+	}
+
+	g, err := p.geometry()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind != tokEOF {
+		return nil, p.unexpected(tokEOF)
+	}
+
+	g.SetSRID(srid)
+
+	return g, nil
+}
+
+// srid reads the optional SRID=n; prefix of EWKT, returning 0 if there is
+// none.
+func (p *parser) srid() (int, error) {
+
+	if p.tok.kind != tokKeyword || !equalFold(p.tok.text, "SRID") {
+		return 0, nil
+	}
+	if err := p.advance(); err != nil {
+		return 0, err
+	}
+
+	if _, err := p.expect(tokEquals); err != nil {
+		return 0, err
+	}
+
+	tok, err := p.expect(tokNumber)
+	if err != nil {
+		return 0, err
+	}
+	srid, err := strconv.Atoi(string(tok.text))
+	if err != nil {
+		return 0, p.errorf(tok.pos, "integer SRID", fmt.Sprintf("%q", tok.text))
+	}
+
+	if _, err := p.expect(tokSemicolon); err != nil {
+		return 0, err
+	}
+
+	return srid, nil
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) expect(kind tokenKind) (token, error) {
+	tok := p.tok
+	if tok.kind != kind {
+		return tok, p.unexpected(kind)
+	}
+	return tok, p.advance()
+}
+
+func (p *parser) unexpected(expected tokenKind) error {
+	return p.errorf(p.tok.pos, expected.String(), p.found())
+}
+
+// found describes the current token for error messages.
+func (p *parser) found() string {
+	if len(p.tok.text) == 0 {
+		return p.tok.kind.String()
+	}
+	return fmt.Sprintf("%q", p.tok.text)
+}
+
+func (p *parser) errorf(offset int, expected string, found string) error {
+	return newParseError(p.lex.input, offset, expected, found)
+}
+
+// geometry parses <geometry tagged text>.
+func (p *parser) geometry() (Geometry, error) {
+
+	kw, err := p.expect(tokKeyword)
+	if err != nil {
+		return nil, err
+	}
+	name, legacyM := geometryName(kw.text)
+
+	p.layout, p.layoutKnown = XY, false
+	if legacyM {
+		p.layout, p.layoutKnown = XYM, true
+	} else if p.tok.kind == tokDimension {
+		p.layout, p.layoutKnown = dimension(p.tok.text), true
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	g, err := p.text(name, kw)
+	if err != nil {
+		return nil, err
+	}
+
+	fixLayout(g, p.layout)
+
+	return g, nil
+}
+
+// text parses the body of a geometry of the named type, kw being the keyword
+// token that introduced it.
+func (p *parser) text(name string, kw token) (Geometry, error) {
+
+	switch name {
+	case "POINT":
+		return p.pointText()
+	case "LINESTRING":
+		return p.lineStringText()
+	case "POLYGON":
+		return p.polygonText()
+	case "MULTIPOINT":
+		return p.multiPointText()
+	case "MULTILINESTRING":
+		return p.multiLineStringText()
+	case "MULTIPOLYGON":
+		polygons, err := p.polygonList()
+		return NewMultiPolygon(p.layout, polygons), err
+	case "TRIANGLE":
+		return p.triangleText()
+	case "TIN":
+		return p.tinText()
+	case "POLYHEDRALSURFACE":
+		polygons, err := p.polygonList()
+		return NewPolyhedralSurface(p.layout, polygons), err
+	case "CIRCULARSTRING":
+		return p.circularStringText()
+	case "COMPOUNDCURVE":
+		curves, err := p.curveList("LINESTRING", "CIRCULARSTRING")
+		return NewCompoundCurve(p.layout, curves), err
+	case "CURVEPOLYGON":
+		curves, err := p.curveList("LINESTRING", "CIRCULARSTRING", "COMPOUNDCURVE")
+		return NewCurvePolygon(p.layout, curves), err
+	case "MULTICURVE":
+		curves, err := p.curveList("LINESTRING", "CIRCULARSTRING", "COMPOUNDCURVE")
+		return NewMultiCurve(p.layout, curves), err
+	case "MULTISURFACE":
+		return p.multiSurfaceText()
+	case "GEOMETRYCOLLECTION":
+		layout, known := p.layout, p.layoutKnown
+		g, err := p.collectionText()
+		p.layout, p.layoutKnown = layout, known
+		return g, err
+	}
+
+	return nil, p.errorf(kw.pos, "geometry type", fmt.Sprintf("%q", kw.text))
+}
+
+func (p *parser) pointText() (*Point, error) {
+
+	if empty, err := p.empty(); empty || err != nil {
+		return NewEmptyPoint(p.layout), err
+	}
+
+	if _, err := p.expect(tokLParen); err != nil {
+		return nil, err
+	}
+	coordinate, err := p.point()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(tokRParen); err != nil {
+		return nil, err
+	}
+
+	return NewPoint(p.layout, coordinate), nil
+}
+
+func (p *parser) lineStringText() (*LineString, error) {
+
+	if empty, err := p.empty(); empty || err != nil {
+		return NewLineString(p.layout, nil), err
+	}
+
+	coordinates, err := p.pointList()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLineString(p.layout, coordinates), nil
+}
+
+func (p *parser) polygonText() (*Polygon, error) {
+	rings, err := p.ringList()
+	if err != nil {
+		return nil, err
+	}
+	return NewPolygon(p.layout, rings), nil
+}
+
+// triangleText reads a polygon that must have exactly one ring of four
+// points, the last of which closes the ring.
+func (p *parser) triangleText() (*Triangle, error) {
+
+	start := p.tok.pos
+
+	rings, err := p.ringList()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rings) > 1 {
+		return nil, p.errorf(start, "1 ring in triangle", strconv.Itoa(len(rings)))
+	}
+	if len(rings) == 1 && len(rings[0]) != 4 {
+		return nil, p.errorf(start, "4 points in triangle", strconv.Itoa(len(rings[0])))
+	}
+
+	return NewTriangle(p.layout, rings), nil
+}
+
+// multiPointText accepts both MULTIPOINT (1 2, 3 4) and the bracketed
+// MULTIPOINT ((1 2), (3 4)) form, with EMPTY members in either.
+func (p *parser) multiPointText() (*MultiPoint, error) {
+
+	multipoint := NewMultiPoint(p.layout, nil)
+
+	err := p.list(func() error {
+		switch p.tok.kind {
+		case tokEmpty:
+			multipoint.Points = append(multipoint.Points, NewEmptyPoint(p.layout))
+			return p.advance()
+		case tokLParen:
+			point, err := p.pointText()
+			if err != nil {
+				return err
+			}
+			multipoint.Points = append(multipoint.Points, point)
+			return nil
+		}
+		coordinate, err := p.point()
+		if err != nil {
+			return err
+		}
+		multipoint.Points = append(multipoint.Points, NewPoint(p.layout, coordinate))
+		return nil
+	})
+
+	return multipoint, err
+}
+
+func (p *parser) multiLineStringText() (*MultiLineString, error) {
+
+	multiline := NewMultiLineString(p.layout, nil)
+
+	err := p.list(func() error {
+		line, err := p.lineStringText()
+		if err != nil {
+			return err
+		}
+		multiline.LineStrings = append(multiline.LineStrings, line)
+		return nil
+	})
+
+	return multiline, err
+}
+
+// polygonList reads the polygons of a MULTIPOLYGON or POLYHEDRALSURFACE.
+func (p *parser) polygonList() ([]*Polygon, error) {
+
+	var polygons []*Polygon
+
+	err := p.list(func() error {
+		polygon, err := p.polygonText()
+		if err != nil {
+			return err
+		}
+		polygons = append(polygons, polygon)
+		return nil
+	})
+
+	return polygons, err
+}
+
+func (p *parser) tinText() (*TIN, error) {
+
+	tin := NewTIN(p.layout, nil)
+
+	err := p.list(func() error {
+		triangle, err := p.triangleText()
+		if err != nil {
+			return err
+		}
+		tin.Triangles = append(tin.Triangles, triangle)
+		return nil
+	})
+
+	return tin, err
+}
+
+// circularStringText reads the control points of a CIRCULARSTRING. Each arc
+// is given by three points and consecutive arcs share an end point, so there
+// must be an odd number of at least three points.
+func (p *parser) circularStringText() (*CircularString, error) {
+
+	start := p.tok.pos
+
+	line, err := p.lineStringText()
+	if err != nil {
+		return nil, err
+	}
+
+	if n := len(line.Coordinates); n > 0 && (n < 3 || n%2 == 0) {
+		return nil, p.errorf(start, "odd number of at least 3 points in circular string", strconv.Itoa(n))
+	}
+
+	return NewCircularString(p.layout, line.Coordinates), nil
+}
+
+// curveList reads the members of a COMPOUNDCURVE, CURVEPOLYGON or
+// MULTICURVE. A member is either a bare bracketed list, read as a
+// LINESTRING, or one of the allowed types tagged with its keyword.
+func (p *parser) curveList(allowed ...string) ([]Curve, error) {
+
+	var curves []Curve
+
+	err := p.list(func() error {
+		if p.tok.kind == tokLParen {
+			line, err := p.lineStringText()
+			if err != nil {
+				return err
+			}
+			curves = append(curves, line)
+			return nil
+		}
+		member, err := p.member(allowed)
+		if err != nil {
+			return err
+		}
+		curves = append(curves, member.(Curve))
+		return nil
+	})
+
+	return curves, err
+}
+
+// multiSurfaceText reads the members of a MULTISURFACE, where a bare
+// bracketed list is a POLYGON.
+func (p *parser) multiSurfaceText() (*MultiSurface, error) {
+
+	multisurface := NewMultiSurface(p.layout, nil)
+
+	err := p.list(func() error {
+		if p.tok.kind == tokLParen {
+			polygon, err := p.polygonText()
+			if err != nil {
+				return err
+			}
+			multisurface.Surfaces = append(multisurface.Surfaces, polygon)
+			return nil
+		}
+		member, err := p.member([]string{"POLYGON", "CURVEPOLYGON"})
+		if err != nil {
+			return err
+		}
+		multisurface.Surfaces = append(multisurface.Surfaces, member.(Surface))
+		return nil
+	})
+
+	return multisurface, err
+}
+
+// member reads a tagged member of one of the curve types. Unlike the
+// members of a GEOMETRYCOLLECTION it shares the layout of the geometry that
+// contains it, so a dimension tag is optional but must agree.
+func (p *parser) member(allowed []string) (Geometry, error) {
+
+	kw, err := p.expect(tokKeyword)
+	if err != nil {
+		return nil, err
+	}
+	name, legacyM := geometryName(kw.text)
+	if !contains(allowed, name) {
+		return nil, p.errorf(kw.pos, strings.Join(allowed, " or "), fmt.Sprintf("%q", kw.text))
+	}
+
+	if legacyM || p.tok.kind == tokDimension {
+		layout, pos := XYM, kw.pos
+		if !legacyM {
+			layout, pos = dimension(p.tok.text), p.tok.pos
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+		if p.layoutKnown && layout != p.layout {
+			return nil, p.errorf(pos, typeName(name, p.layout.Tag()), fmt.Sprintf("%q", typeName(name, layout.Tag())))
+		}
+		p.layout, p.layoutKnown = layout, true
+	}
+
+	return p.text(name, kw)
+}
+
+// collectionText reads the members of a GEOMETRYCOLLECTION, which may
+// themselves be collections, up to maxWKTDepth deep.
+func (p *parser) collectionText() (*GeometryCollection, error) {
+
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxWKTDepth {
+		return nil, p.errorf(p.tok.pos, fmt.Sprintf("collections nested at most %d deep", maxWKTDepth), strconv.Itoa(p.depth))
+	}
+
+	collection := NewGeometryCollection(p.layout, nil)
+
+	err := p.list(func() error {
+		g, err := p.geometry()
+		if err != nil {
+			return err
+		}
+		collection.Geometries = append(collection.Geometries, g)
+		return nil
+	})
+
+	return collection, err
+}
+
+// list reads EMPTY or a bracketed, comma separated list, calling member to
+// read each item.
+func (p *parser) list(member func() error) error {
+
+	if empty, err := p.empty(); empty || err != nil {
+		return err
+	}
+
+	if _, err := p.expect(tokLParen); err != nil {
+		return err
+	}
+
+	for {
+		if err := member(); err != nil {
+			return err
+		}
+
+		if p.tok.kind != tokComma {
+			break
+		}
+		if err := p.advance(); err != nil {
+			return err
+		}
+	}
+
+	_, err := p.expect(tokRParen)
+	return err
+}
+
+// ringList reads the rings of a polygon in order, the shell first and then
+// any holes.
+func (p *parser) ringList() ([][]Coordinate, error) {
+
+	start := len(p.rings)
+
+	err := p.list(func() error {
+		start := p.tok.pos
+
+		ring, err := p.pointList()
+		if err != nil {
+			return err
+		}
+
+		if err := p.checkRing(start, ring); err != nil {
+			return err
+		}
+
+		p.rings = append(p.rings, ring)
+		return nil
+	})
+
+	if err != nil || len(p.rings) == start {
+		return nil, err
+	}
+	return p.rings[start:len(p.rings):len(p.rings)], nil
+}
+
+// checkRing reports a ring, which started at offset start, that is not a
+// linear ring: one with at least four points whose last point closes it.
+func (p *parser) checkRing(start int, ring []Coordinate) error {
+
+	if len(ring) < 4 {
+		return p.errorf(start, "at least 4 points in ring", strconv.Itoa(len(ring)))
+	}
+	if ring[0] != ring[len(ring)-1] {
+		return p.errorf(start, "closed ring", "ring that ends at a different point")
+	}
+
+	return nil
+}
+
+// pointList reads a parenthesised, comma separated list of points.
+func (p *parser) pointList() ([]Coordinate, error) {
+
+	start := len(p.coordinates)
+
+	err := p.list(func() error {
+		coordinate, err := p.point()
+		if err != nil {
+			return err
+		}
+		p.coordinates = append(p.coordinates, coordinate)
+		return nil
+	})
+
+	if err != nil || len(p.coordinates) == start {
+		return nil, err
+	}
+	return p.coordinates[start:len(p.coordinates):len(p.coordinates)], nil
+}
+
+// point reads the ordinates of a single coordinate and checks that their
+// number matches the dimension of the geometry.
+func (p *parser) point() (Coordinate, error) {
+
+	start := p.tok.pos
+	var ords [4]float64
+	n := 0
+
+	for p.tok.kind == tokNumber {
+		if n < len(ords) {
+			f, err := parseNumber(p.tok.text)
+			if err != nil {
+				return Coordinate{}, p.errorf(p.tok.pos, "number", p.found())
+			}
+			ords[n] = f
+		}
+		n++
+		if err := p.advance(); err != nil {
+			return Coordinate{}, err
+		}
+	}
+
+	if n == 0 {
+		return Coordinate{}, p.unexpected(tokNumber)
+	}
+
+	if !p.layoutKnown {
+		if n < 2 || n > 4 {
+			return Coordinate{}, p.errorf(start, "2 to 4 ordinates", strconv.Itoa(n))
+		}
+		switch n {
+		case 3:
+			p.layout = XYZ
+		case 4:
+			p.layout = XYZM
+		}
+		p.layoutKnown = true
+	}
+
+	if want := p.layout.Stride(); n != want {
+		return Coordinate{}, p.errorf(start, strconv.Itoa(want)+" ordinates", strconv.Itoa(n))
+	}
+
+	switch p.layout {
+	case XYZ:
+		return Coordinate{X: ords[0], Y: ords[1], Z: ords[2]}, nil
+	case XYM:
+		return Coordinate{X: ords[0], Y: ords[1], M: ords[2]}, nil
+	case XYZM:
+		return Coordinate{X: ords[0], Y: ords[1], Z: ords[2], M: ords[3]}, nil
+	}
+	return Coordinate{X: ords[0], Y: ords[1]}, nil
+}
+
+// empty consumes an EMPTY keyword if one is next and reports whether it did.
+func (p *parser) empty() (bool, error) {
+	if p.tok.kind != tokEmpty {
+		return false, nil
+	}
+	return true, p.advance()
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+var geometryTypes = []string{
+	"POINT", "LINESTRING", "POLYGON", "MULTIPOINT", "MULTILINESTRING", "MULTIPOLYGON",
+	"TRIANGLE", "TIN", "POLYHEDRALSURFACE", "GEOMETRYCOLLECTION",
+	"CIRCULARSTRING", "COMPOUNDCURVE", "CURVEPOLYGON", "MULTICURVE", "MULTISURFACE",
+}
+
+// geometryName returns the upper case name of the geometry type that a
+// keyword in any case names, or "" if it names none. The PostGIS spelling of
+// an M geometry keyword, such as POINTM, gives the plain type name and true.
+// No geometry type ends in M, so this is never ambiguous.
+func geometryName(word []byte) (string, bool) {
+	for _, name := range geometryTypes {
+		if equalFold(word, name) {
+			return name, false
+		}
+		if n := len(name); len(word) == n+1 && (word[n] == 'M' || word[n] == 'm') && equalFold(word[:n], name) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// equalFold reports whether word, in any case, is the upper case keyword.
+// It only folds ASCII letters, which are all that WKT keywords contain.
+func equalFold(word []byte, keyword string) bool {
+	if len(word) != len(keyword) {
+		return false
+	}
+	for i, c := range word {
+		if 'a' <= c && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		if c != keyword[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func typeName(name string, dim string) string {
+	if dim == "" {
+		return name
+	}
+	return name + " " + dim
+}
+
+// splitType splits a geometry type such as "POLYGON ZM" into its name and
+// dimension tag.
+func splitType(wkttype string) (string, string) {
+	if i := strings.LastIndex(wkttype, " "); i != -1 {
+		return wkttype[:i], wkttype[i+1:]
+	}
+	return wkttype, ""
+}
+
+// fixLayout gives g and all of its parts the layout of the geometry that was
+// just parsed. Parts read before the layout was known, such as EMPTY members
+// ahead of the first coordinate, would otherwise be left as XY. Members of a
+// collection keep their own layouts.
+func fixLayout(g Geometry, layout Layout) {
+
+	switch g := g.(type) {
+	case *Point:
+		g.setLayout(layout)
+	case *LineString:
+		g.setLayout(layout)
+	case *Polygon:
+		g.setLayout(layout)
+	case *Triangle:
+		g.setLayout(layout)
+	case *CircularString:
+		g.setLayout(layout)
+	case *MultiPoint:
+		g.setLayout(layout)
+		for _, point := range g.Points {
+			point.setLayout(layout)
+		}
+	case *MultiLineString:
+		g.setLayout(layout)
+		for _, line := range g.LineStrings {
+			line.setLayout(layout)
+		}
+	case *MultiPolygon:
+		g.setLayout(layout)
+		for _, polygon := range g.Polygons {
+			polygon.setLayout(layout)
+		}
+	case *PolyhedralSurface:
+		g.setLayout(layout)
+		for _, polygon := range g.Polygons {
+			polygon.setLayout(layout)
+		}
+	case *TIN:
+		g.setLayout(layout)
+		for _, triangle := range g.Triangles {
+			triangle.setLayout(layout)
+		}
+	case *CompoundCurve:
+		g.setLayout(layout)
+		for _, segment := range g.Segments {
+			fixLayout(segment, layout)
+		}
+	case *CurvePolygon:
+		g.setLayout(layout)
+		for _, ring := range g.Rings {
+			fixLayout(ring, layout)
+		}
+	case *MultiCurve:
+		g.setLayout(layout)
+		for _, curve := range g.Curves {
+			fixLayout(curve, layout)
+		}
+	case *MultiSurface:
+		g.setLayout(layout)
+		for _, surface := range g.Surfaces {
+			fixLayout(surface, layout)
+		}
+	case *GeometryCollection:
+		g.setLayout(layout)
+	}
+}
@@ -0,0 +1,443 @@
+package wktparse
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Geometry type codes of ISO WKB (ISO 13249-3 and OGC 06-103r4). The
+// dimension is added on as 1000 for Z, 2000 for M and 3000 for ZM.
+const (
+	wkbPoint              uint32 = 1
+	wkbLineString         uint32 = 2
+	wkbPolygon            uint32 = 3
+	wkbMultiPoint         uint32 = 4
+	wkbMultiLineString    uint32 = 5
+	wkbMultiPolygon       uint32 = 6
+	wkbGeometryCollection uint32 = 7
+	wkbCircularString     uint32 = 8
+	wkbCompoundCurve      uint32 = 9
+	wkbCurvePolygon       uint32 = 10
+	wkbMultiCurve         uint32 = 11
+	wkbMultiSurface       uint32 = 12
+	wkbPolyhedralSurface  uint32 = 15
+	wkbTIN                uint32 = 16
+	wkbTriangle           uint32 = 17
+)
+
+// Flags PostGIS EWKB sets in the high bits of the type instead of adding
+// ISO dimension offsets. With ewkbSRID set, the type is followed by a 32 bit
+// SRID.
+const (
+	ewkbZ    uint32 = 0x80000000
+	ewkbM    uint32 = 0x40000000
+	ewkbSRID uint32 = 0x20000000
+	ewkbMask        = ewkbZ | ewkbM | ewkbSRID
+)
+
+// Byte order markers at the start of every WKB geometry.
+const (
+	wkbXDR byte = 0 // Big endian
+	wkbNDR byte = 1 // Little endian
+)
+
+// Nesting limit for collections, so that a small, malicious input cannot
+// recurse without bound.
+const maxWKBDepth = 128
+
+// The smallest possible geometry is a byte order, a type and a count.
+const minWKBGeometry = 1 + 4 + 4
+
+// ParseWKB decodes a geometry in ISO Well Known Binary or PostGIS Extended
+// WKB (EWKB), in either byte order. It returns the same geometry types as
+// Parse does for the WKT or EWKT of the same geometry, including the SRID of
+// EWKB. A point whose ordinates are all NaN is POINT EMPTY, as written by
+// PostGIS. If the input is not valid WKB the error is a *WKBError.
+func ParseWKB(data []byte) (Geometry, error) {
+
+	r := &wkbReader{data: data}
+
+	g, err := r.geometry()
+	if err != nil {
+		return nil, err
+	}
+
+	if r.pos != len(r.data) {
+		return nil, r.errorf("%d bytes left over after geometry", len(r.data)-r.pos)
+	}
+
+	return g, nil
+}
+
+type wkbReader struct {
+	data  []byte
+	pos   int
+	order binary.ByteOrder
+	depth int
+}
+
+func (r *wkbReader) errorf(format string, args ...interface{}) error {
+	return &WKBError{Offset: r.pos, Reason: fmt.Sprintf(format, args...)}
+}
+
+func (r *wkbReader) need(n int) error {
+	if n < 0 || len(r.data)-r.pos < n {
+		return r.errorf("unexpected end of input, need %d more bytes", n)
+	}
+	return nil
+}
+
+func (r *wkbReader) readByte() (byte, error) {
+	if err := r.need(1); err != nil {
+		return 0, err
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *wkbReader) readUint32() (uint32, error) {
+	if err := r.need(4); err != nil {
+		return 0, err
+	}
+	v := r.order.Uint32(r.data[r.pos:])
+	r.pos += 4
+	return v, nil
+}
+
+func (r *wkbReader) readFloat64() float64 {
+	v := math.Float64frombits(r.order.Uint64(r.data[r.pos:]))
+	r.pos += 8
+	return v
+}
+
+// count reads the number of items that follow and checks that the input is
+// long enough to hold that many of at least size bytes each, so a huge
+// count in a short input cannot make us allocate.
+func (r *wkbReader) count(size int) (int, error) {
+	start := r.pos
+	n, err := r.readUint32()
+	if err != nil {
+		return 0, err
+	}
+	if uint64(n)*uint64(size) > uint64(len(r.data)-r.pos) {
+		r.pos = start
+		return 0, r.errorf("count of %d does not fit in the %d bytes left", n, len(r.data)-r.pos-4)
+	}
+	return int(n), nil
+}
+
+// header reads the byte order and type of a geometry and returns the type
+// code without its dimension, the layout and the SRID, which is 0 unless
+// this is EWKB with an SRID.
+func (r *wkbReader) header() (uint32, Layout, int, error) {
+
+	order, err := r.readByte()
+	if err != nil {
+		return 0, XY, 0, err
+	}
+	switch order {
+	case wkbXDR:
+		r.order = binary.BigEndian
+	case wkbNDR:
+		r.order = binary.LittleEndian
+	default:
+		r.pos--
+		return 0, XY, 0, r.errorf("invalid byte order %d", order)
+	}
+
+	start := r.pos
+	code, err := r.readUint32()
+	if err != nil {
+		return 0, XY, 0, err
+	}
+
+	flags := code & ewkbMask
+	code &^= ewkbMask
+
+	if code/1000 > 3 || (flags != 0 && code >= 1000) {
+		r.pos = start
+		return 0, XY, 0, r.errorf("unknown geometry type %d", code|flags)
+	}
+
+	layout := Layout(code / 1000)
+	switch flags & (ewkbZ | ewkbM) {
+	case ewkbZ:
+		layout = XYZ
+	case ewkbM:
+		layout = XYM
+	case ewkbZ | ewkbM:
+		layout = XYZM
+	}
+
+	srid := 0
+	if flags&ewkbSRID != 0 {
+		v, err := r.readUint32()
+		if err != nil {
+			return 0, XY, 0, err
+		}
+		srid = int(int32(v))
+	}
+
+	return code % 1000, layout, srid, nil
+}
+
+// geometry reads one complete WKB geometry.
+func (r *wkbReader) geometry() (Geometry, error) {
+
+	start := r.pos
+
+	code, layout, srid, err := r.header()
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := r.body(start, code, layout)
+	if err != nil {
+		return nil, err
+	}
+
+	g.SetSRID(srid)
+
+	return g, nil
+}
+
+// body reads the rest of a geometry that started at start, once its header
+// has been read.
+func (r *wkbReader) body(start int, code uint32, layout Layout) (Geometry, error) {
+
+	switch code {
+	case wkbPoint:
+		return r.point(layout)
+
+	case wkbLineString:
+		coordinates, err := r.coordinates(layout)
+		return NewLineString(layout, coordinates), err
+
+	case wkbPolygon:
+		rings, err := r.rings(layout)
+		return NewPolygon(layout, rings), err
+
+	case wkbTriangle:
+		return r.triangle(layout)
+
+	case wkbCircularString:
+		coordinates, err := r.coordinates(layout)
+		if n := len(coordinates); err == nil && n > 0 && (n < 3 || n%2 == 0) {
+			err = &WKBError{Offset: start, Reason: fmt.Sprintf("circular string has %d points, need an odd number of at least 3", n)}
+		}
+		return NewCircularString(layout, coordinates), err
+	}
+
+	// The rest are made of other geometries.
+	r.depth++
+	defer func() { r.depth-- }()
+	if r.depth > maxWKBDepth {
+		r.pos = start
+		return nil, r.errorf("geometries nested more than %d deep", maxWKBDepth)
+	}
+
+	switch code {
+	case wkbMultiPoint:
+		multipoint := NewMultiPoint(layout, nil)
+		err := r.members(layout, func(g Geometry) bool {
+			point, ok := g.(*Point)
+			multipoint.Points = append(multipoint.Points, point)
+			return ok
+		})
+		return multipoint, err
+
+	case wkbMultiLineString:
+		multiline := NewMultiLineString(layout, nil)
+		err := r.members(layout, func(g Geometry) bool {
+			line, ok := g.(*LineString)
+			multiline.LineStrings = append(multiline.LineStrings, line)
+			return ok
+		})
+		return multiline, err
+
+	case wkbMultiPolygon, wkbPolyhedralSurface:
+		var polygons []*Polygon
+		err := r.members(layout, func(g Geometry) bool {
+			polygon, ok := g.(*Polygon)
+			polygons = append(polygons, polygon)
+			return ok
+		})
+		if code == wkbMultiPolygon {
+			return NewMultiPolygon(layout, polygons), err
+		}
+		return NewPolyhedralSurface(layout, polygons), err
+
+	case wkbTIN:
+		tin := NewTIN(layout, nil)
+		err := r.members(layout, func(g Geometry) bool {
+			triangle, ok := g.(*Triangle)
+			tin.Triangles = append(tin.Triangles, triangle)
+			return ok
+		})
+		return tin, err
+
+	case wkbCompoundCurve:
+		compound := NewCompoundCurve(layout, nil)
+		err := r.members(layout, func(g Geometry) bool {
+			switch g.(type) {
+			case *LineString, *CircularString:
+				compound.Segments = append(compound.Segments, g.(Curve))
+				return true
+			}
+			return false
+		})
+		return compound, err
+
+	case wkbCurvePolygon, wkbMultiCurve:
+		var curves []Curve
+		err := r.members(layout, func(g Geometry) bool {
+			curve, ok := g.(Curve)
+			curves = append(curves, curve)
+			return ok
+		})
+		if code == wkbCurvePolygon {
+			return NewCurvePolygon(layout, curves), err
+		}
+		return NewMultiCurve(layout, curves), err
+
+	case wkbMultiSurface:
+		multisurface := NewMultiSurface(layout, nil)
+		err := r.members(layout, func(g Geometry) bool {
+			surface, ok := g.(Surface)
+			multisurface.Surfaces = append(multisurface.Surfaces, surface)
+			return ok
+		})
+		return multisurface, err
+
+	case wkbGeometryCollection:
+		collection := NewGeometryCollection(layout, nil)
+		n, err := r.count(minWKBGeometry)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < n; i++ {
+			g, err := r.geometry()
+			if err != nil {
+				return nil, err
+			}
+			collection.Geometries = append(collection.Geometries, g)
+		}
+		return collection, nil
+	}
+
+	r.pos = start + 1
+	return nil, r.errorf("unknown geometry type %d", code)
+}
+
+// members reads the members of a multi-part geometry other than a
+// collection. Each must have the layout of the geometry that contains it,
+// and add must return whether it is of a type allowed there.
+func (r *wkbReader) members(layout Layout, add func(Geometry) bool) error {
+
+	n, err := r.count(minWKBGeometry)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < n; i++ {
+		start := r.pos
+		g, err := r.geometry()
+		if err != nil {
+			return err
+		}
+		if g.Layout() != layout {
+			return &WKBError{Offset: start, Reason: fmt.Sprintf("member is %s in a geometry that is %s", g.Layout(), layout)}
+		}
+		if !add(g) {
+			return &WKBError{Offset: start, Reason: fmt.Sprintf("%s is not allowed here", g.Type())}
+		}
+	}
+
+	return nil
+}
+
+func (r *wkbReader) point(layout Layout) (*Point, error) {
+
+	if err := r.need(8 * layout.Stride()); err != nil {
+		return nil, err
+	}
+
+	c := r.coordinate(layout)
+	if math.IsNaN(c.X) && math.IsNaN(c.Y) {
+		return NewEmptyPoint(layout), nil
+	}
+
+	return NewPoint(layout, c), nil
+}
+
+func (r *wkbReader) triangle(layout Layout) (*Triangle, error) {
+
+	start := r.pos
+
+	rings, err := r.rings(layout)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rings) > 1 {
+		return nil, &WKBError{Offset: start, Reason: fmt.Sprintf("triangle has %d rings, need 1", len(rings))}
+	}
+	if len(rings) == 1 && len(rings[0]) != 4 {
+		return nil, &WKBError{Offset: start, Reason: fmt.Sprintf("triangle has %d points, need 4", len(rings[0]))}
+	}
+
+	return NewTriangle(layout, rings), nil
+}
+
+func (r *wkbReader) rings(layout Layout) ([][]Coordinate, error) {
+
+	n, err := r.count(4)
+	if err != nil || n == 0 {
+		return nil, err
+	}
+
+	rings := make([][]Coordinate, n)
+	for i := range rings {
+		if rings[i], err = r.coordinates(layout); err != nil {
+			return nil, err
+		}
+	}
+
+	return rings, nil
+}
+
+func (r *wkbReader) coordinates(layout Layout) ([]Coordinate, error) {
+
+	n, err := r.count(8 * layout.Stride())
+	if err != nil || n == 0 {
+		return nil, err
+	}
+
+	coordinates := make([]Coordinate, n)
+	for i := range coordinates {
+		coordinates[i] = r.coordinate(layout)
+	}
+
+	return coordinates, nil
+}
+
+// coordinate reads one coordinate. The caller must have checked that the
+// input holds enough bytes for it.
+func (r *wkbReader) coordinate(layout Layout) Coordinate {
+
+	c := Coordinate{X: r.readFloat64(), Y: r.readFloat64()}
+
+	switch layout {
+	case XYZ:
+		c.Z = r.readFloat64()
+	case XYM:
+		c.M = r.readFloat64()
+	case XYZM:
+		c.Z = r.readFloat64()
+		c.M = r.readFloat64()
+	}
+
+	return c
+}
@@ -0,0 +1,173 @@
+package wktparse
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// wkbFixtures pairs WKT with the same geometry in WKB, in a mix of byte
+// orders.
+var wkbFixtures = []struct {
+	wkt string
+	wkb string
+}{
+	{"POINT (1 2)", "0101000000000000000000F03F0000000000000040"},
+	{"POINT (1 2)", "00000000013FF00000000000004000000000000000"},
+	{"POINT Z (1 2 3)", "01E9030000000000000000F03F00000000000000400000000000000840"},
+	{"POINT M (1 2 4)", "00000007D13FF000000000000040000000000000004010000000000000"},
+	{"POINT ZM (1 2 3 4)", "01B90B0000000000000000F03F000000000000004000000000000008400000000000001040"},
+	{"POINT EMPTY", "0101000000000000000000F87F000000000000F87F"},
+	{"LINESTRING (30 10, 10 30, 40 40)", "0102000000030000000000000000003E40000000000000244000000000000024400000000000003E4000000000000044400000000000004440"},
+	{"LINESTRING EMPTY", "000000000200000000"},
+	{"POLYGON ((35 10, 45 45, 15 40, 10 20, 35 10), (20 30, 35 35, 30 20, 20 30))", "0103000000020000000500000000000000008041400000000000002440000000000080464000000000008046400000000000002E40000000000000444000000000000024400000000000003440000000000080414000000000000024400400000000000000000034400000000000003E40000000000080414000000000008041400000000000003E40000000000000344000000000000034400000000000003E40"},
+	{"POLYGON Z EMPTY", "00000003EB00000000"},
+	{"MULTIPOINT ((10 40), (40 30))", "01040000000200000001010000000000000000002440000000000000444000000000014044000000000000403E000000000000"},
+	{"MULTIPOINT ZM ((10 40 1 7), EMPTY)", "0000000BBC0000000201B90B000000000000000024400000000000004440000000000000F03F0000000000001C4001B90B0000000000000000F87F000000000000F87F000000000000F87F000000000000F87F"},
+	{"MULTILINESTRING M ((10 10 1, 20 20 2), EMPTY)", "01D50700000200000001D20700000200000000000000000024400000000000002440000000000000F03F00000000000034400000000000003440000000000000004001D207000000000000"},
+	{"MULTIPOLYGON (((0 0, 1 0, 1 1, 0 0)), EMPTY)", "0106000000020000000103000000010000000400000000000000000000000000000000000000000000000000F03F0000000000000000000000000000F03F000000000000F03F00000000000000000000000000000000000000000300000000"},
+	{"GEOMETRYCOLLECTION (POINT Z (1 2 3), GEOMETRYCOLLECTION (LINESTRING M (1 2 5, 3 4 6), GEOMETRYCOLLECTION EMPTY))", "01070000000200000001E9030000000000000000F03F0000000000000040000000000000084000000000070000000201D207000002000000000000000000F03F00000000000000400000000000001440000000000000084000000000000010400000000000001840010700000000000000"},
+	{"TRIANGLE Z ((0 0 1, 1 0 1, 1 1 1, 0 0 1))", "01F9030000010000000400000000000000000000000000000000000000000000000000F03F000000000000F03F0000000000000000000000000000F03F000000000000F03F000000000000F03F000000000000F03F00000000000000000000000000000000000000000000F03F"},
+	{"TIN Z (((0 0 1, 1 0 1, 1 1 1, 0 0 1)), ((0 0 1, 1 0 1, 1 1 1, 0 0 1)))", "01F80300000200000001F9030000010000000400000000000000000000000000000000000000000000000000F03F000000000000F03F0000000000000000000000000000F03F000000000000F03F000000000000F03F000000000000F03F00000000000000000000000000000000000000000000F03F00000003F90000000100000004000000000000000000000000000000003FF00000000000003FF000000000000000000000000000003FF00000000000003FF00000000000003FF00000000000003FF0000000000000000000000000000000000000000000003FF0000000000000"},
+	{"POLYHEDRALSURFACE (((0 0, 1 0, 1 1, 0 0)))", "000000000F0000000100000000030000000100000004000000000000000000000000000000003FF000000000000000000000000000003FF00000000000003FF000000000000000000000000000000000000000000000"},
+	{"CIRCULARSTRING (0 0, 1 1, 2 0)", "01080000000300000000000000000000000000000000000000000000000000F03F000000000000F03F00000000000000400000000000000000"},
+	{"COMPOUNDCURVE Z (CIRCULARSTRING (0 0 1, 1 1 1, 2 0 1), (2 0 1, 4 0 2))", "01F10300000200000001F00300000300000000000000000000000000000000000000000000000000F03F000000000000F03F000000000000F03F000000000000F03F00000000000000400000000000000000000000000000F03F01EA0300000200000000000000000000400000000000000000000000000000F03F000000000000104000000000000000000000000000000040"},
+	{"CURVEPOLYGON (COMPOUNDCURVE (CIRCULARSTRING (0 0, 2 2, 4 0), (4 0, 0 0)), (1 0.5, 2 0.5, 2 1, 1 0.5))", "010A0000000200000001090000000200000001080000000300000000000000000000000000000000000000000000000000004000000000000000400000000000001040000000000000000001020000000200000000000000000010400000000000000000000000000000000000000000000000000000000002000000043FF00000000000003FE000000000000040000000000000003FE000000000000040000000000000003FF00000000000003FF00000000000003FE0000000000000"},
+	{"MULTICURVE ((0 0, 5 5), CIRCULARSTRING (4 0, 4 4, 8 4))", "010B000000020000000102000000020000000000000000000000000000000000000000000000000014400000000000001440010800000003000000000000000000104000000000000000000000000000001040000000000000104000000000000020400000000000001040"},
+	{"MULTISURFACE (CURVEPOLYGON (CIRCULARSTRING (0 0, 4 0, 0 0)), ((10 10, 14 12, 11 10, 10 10)))", "010C00000002000000010A0000000100000001080000000300000000000000000000000000000000000000000000000000104000000000000000000000000000000000000000000000000001030000000100000004000000000000000000244000000000000024400000000000002C4000000000000028400000000000002640000000000000244000000000000024400000000000002440"},
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseWKB(t *testing.T) {
+
+	for _, fixture := range wkbFixtures {
+
+		want, err := Parse(fixture.wkt)
+		if err != nil {
+			t.Fatal(fixture.wkt, ": ", err)
+		}
+
+		got, err := ParseWKB(mustDecodeHex(t, fixture.wkb))
+		if err != nil {
+			t.Error(fixture.wkt, ": ", err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Error("Expected ", want, " got ", got)
+		}
+	}
+
+}
+
+func TestParseEWKB(t *testing.T) {
+
+	tests := []struct {
+		wkt  string
+		ewkb string
+	}{
+		{"SRID=4326;POINT (1 2)", "0101000020E6100000000000000000F03F0000000000000040"},
+		{"POINT Z (1 2 3)", "00800000013FF000000000000040000000000000004008000000000000"},
+		{"POINT M (1 2 4)", "0101000040000000000000F03F00000000000000400000000000001040"},
+		{"SRID=3857;LINESTRING ZM (1 2 3 4, 5 6 7 8)", "01020000E0110F000002000000000000000000F03F000000000000004000000000000008400000000000001040000000000000144000000000000018400000000000001C400000000000002040"},
+	}
+
+	for _, test := range tests {
+
+		want, err := Parse(test.wkt)
+		if err != nil {
+			t.Fatal(test.wkt, ": ", err)
+		}
+
+		got, err := ParseWKB(mustDecodeHex(t, test.ewkb))
+		if err != nil {
+			t.Error(test.wkt, ": ", err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Error("Expected ", want, " got ", got)
+		}
+	}
+
+}
+
+func TestParseWKBTruncated(t *testing.T) {
+
+	// Every proper prefix of a valid geometry must fail cleanly.
+	for _, fixture := range wkbFixtures {
+		data := mustDecodeHex(t, fixture.wkb)
+		for n := 0; n < len(data); n++ {
+			_, err := ParseWKB(data[:n])
+			if _, ok := err.(*WKBError); !ok {
+				t.Fatal("Expected a *WKBError for ", n, " bytes of ", fixture.wkt, " got ", err)
+			}
+		}
+	}
+
+}
+
+func TestParseWKBErrors(t *testing.T) {
+
+	tests := []struct {
+		wkb    string
+		offset int
+		reason string
+	}{
+		// Byte order 2.
+		{"0201000000000000000000F03F0000000000000040", 0, "byte order"},
+		// Type 13 and type 4001.
+		{"010D000000", 1, "unknown geometry type 13"},
+		{"01A10F0000", 1, "unknown geometry type 4001"},
+		// A LINESTRING claiming 2^32-1 points.
+		{"0102000000FFFFFFFF", 5, "count of 4294967295"},
+		// A POINT followed by a stray byte.
+		{"0101000000000000000000F03F000000000000004000", 21, "left over"},
+		// A MULTIPOINT Z holding a 2D POINT.
+		{"01EC0300000100000001010000000000000000000000000000000000F03F", 9, "member is XY"},
+		// A MULTIPOINT holding a LINESTRING.
+		{"010400000001000000010200000000000000", 9, "LINESTRING is not allowed"},
+		// A CIRCULARSTRING with 2 points.
+		{"01080000000200000000000000000000000000000000000000000000000000F03F000000000000F03F", 0, "circular string has 2 points"},
+		// A TRIANGLE with 3 points.
+		{"01110000000100000003000000" + strings.Repeat("00", 48), 5, "triangle has 3 points"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseWKB(mustDecodeHex(t, test.wkb))
+		werr, ok := err.(*WKBError)
+		if !ok {
+			t.Error("Expected a *WKBError for ", test.wkb, " got ", err)
+			continue
+		}
+		if werr.Offset != test.offset || !strings.Contains(werr.Reason, test.reason) {
+			t.Error("Expected ", test.reason, " at ", test.offset, " got ", werr)
+		}
+	}
+
+}
+
+func TestParseWKBNesting(t *testing.T) {
+
+	// GEOMETRYCOLLECTIONs nested far deeper than is sensible.
+	var data bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		data.Write([]byte{1, 7, 0, 0, 0, 1, 0, 0, 0})
+	}
+	data.Write([]byte{1, 7, 0, 0, 0, 0, 0, 0, 0})
+
+	_, err := ParseWKB(data.Bytes())
+	if werr, ok := err.(*WKBError); !ok || !strings.Contains(werr.Reason, "nested") {
+		t.Error("Expected a nesting error got ", err)
+	}
+
+}
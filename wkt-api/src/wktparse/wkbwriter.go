@@ -0,0 +1,234 @@
+package wktparse
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// The NaN PostGIS writes for the ordinates of POINT EMPTY. math.NaN has
+// different bits.
+var wkbEmptyOrdinate = math.Float64frombits(0x7FF8000000000000)
+
+// MarshalWKB encodes g as ISO Well Known Binary in the given byte order,
+// which must be binary.BigEndian or binary.LittleEndian. ISO WKB has no
+// SRID, so any SRID on g is dropped; use MarshalEWKB to keep it.
+func MarshalWKB(g Geometry, order binary.ByteOrder) ([]byte, error) {
+	return marshalWKB(g, order, false)
+}
+
+// MarshalEWKB encodes g as PostGIS Extended WKB, as ST_AsEWKB returns it:
+// the dimension is given by flag bits in the type rather than ISO offsets,
+// and the SRID, if g has one, follows the type of the outermost geometry.
+func MarshalEWKB(g Geometry, order binary.ByteOrder) ([]byte, error) {
+	return marshalWKB(g, order, true)
+}
+
+// HexEWKB returns the EWKB of g as upper case hex, the same string
+// ST_AsHEXEWKB returns for the same byte order.
+func HexEWKB(g Geometry, order binary.ByteOrder) (string, error) {
+	data, err := MarshalEWKB(g, order)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToUpper(hex.EncodeToString(data)), nil
+}
+
+func marshalWKB(g Geometry, order binary.ByteOrder, ewkb bool) ([]byte, error) {
+
+	if g == nil {
+		return nil, errors.New("wktparse: cannot marshal a nil geometry")
+	}
+
+	w := &wkbWriter{order: order, ewkb: ewkb}
+
+	switch order {
+	case binary.BigEndian:
+		w.marker = wkbXDR
+	case binary.LittleEndian:
+		w.marker = wkbNDR
+	default:
+		return nil, fmt.Errorf("wktparse: unsupported byte order %v", order)
+	}
+
+	w.geometry(g, ewkb && g.SRID() != 0)
+
+	return w.buf, w.err
+}
+
+type wkbWriter struct {
+	buf     []byte
+	scratch [8]byte
+	order   binary.ByteOrder
+	marker  byte
+	ewkb    bool
+	err     error
+}
+
+func (w *wkbWriter) putUint32(v uint32) {
+	w.order.PutUint32(w.scratch[:4], v)
+	w.buf = append(w.buf, w.scratch[:4]...)
+}
+
+func (w *wkbWriter) putFloat64(v float64) {
+	w.order.PutUint64(w.scratch[:], math.Float64bits(v))
+	w.buf = append(w.buf, w.scratch[:]...)
+}
+
+// header writes the byte order and type of g, and its SRID if withSRID is
+// set.
+func (w *wkbWriter) header(g Geometry, code uint32, withSRID bool) {
+
+	w.buf = append(w.buf, w.marker)
+
+	layout := g.Layout()
+
+	if !w.ewkb {
+		w.putUint32(code + 1000*uint32(layout))
+		return
+	}
+
+	if layout.HasZ() {
+		code |= ewkbZ
+	}
+	if layout.HasM() {
+		code |= ewkbM
+	}
+	if withSRID {
+		code |= ewkbSRID
+	}
+
+	w.putUint32(code)
+	if withSRID {
+		w.putUint32(uint32(int32(g.SRID())))
+	}
+}
+
+func (w *wkbWriter) geometry(g Geometry, withSRID bool) {
+
+	switch g := g.(type) {
+	case *Point:
+		w.header(g, wkbPoint, withSRID)
+		if g.IsEmpty() {
+			for i := 0; i < g.Layout().Stride(); i++ {
+				w.putFloat64(wkbEmptyOrdinate)
+			}
+			return
+		}
+		w.coordinate(g.Layout(), g.Coordinate)
+
+	case *LineString:
+		w.header(g, wkbLineString, withSRID)
+		w.coordinates(g.Layout(), g.Coordinates)
+
+	case *CircularString:
+		w.header(g, wkbCircularString, withSRID)
+		w.coordinates(g.Layout(), g.Coordinates)
+
+	case *Polygon:
+		w.header(g, wkbPolygon, withSRID)
+		w.rings(g.Layout(), g.Rings)
+
+	case *Triangle:
+		w.header(g, wkbTriangle, withSRID)
+		w.rings(g.Layout(), g.Rings)
+
+	case *MultiPoint:
+		w.header(g, wkbMultiPoint, withSRID)
+		w.putUint32(uint32(len(g.Points)))
+		for _, point := range g.Points {
+			w.geometry(point, false)
+		}
+
+	case *MultiLineString:
+		w.header(g, wkbMultiLineString, withSRID)
+		w.putUint32(uint32(len(g.LineStrings)))
+		for _, line := range g.LineStrings {
+			w.geometry(line, false)
+		}
+
+	case *MultiPolygon:
+		w.header(g, wkbMultiPolygon, withSRID)
+		w.putUint32(uint32(len(g.Polygons)))
+		for _, polygon := range g.Polygons {
+			w.geometry(polygon, false)
+		}
+
+	case *PolyhedralSurface:
+		w.header(g, wkbPolyhedralSurface, withSRID)
+		w.putUint32(uint32(len(g.Polygons)))
+		for _, polygon := range g.Polygons {
+			w.geometry(polygon, false)
+		}
+
+	case *TIN:
+		w.header(g, wkbTIN, withSRID)
+		w.putUint32(uint32(len(g.Triangles)))
+		for _, triangle := range g.Triangles {
+			w.geometry(triangle, false)
+		}
+
+	case *CompoundCurve:
+		w.header(g, wkbCompoundCurve, withSRID)
+		w.putUint32(uint32(len(g.Segments)))
+		for _, segment := range g.Segments {
+			w.geometry(segment, false)
+		}
+
+	case *CurvePolygon:
+		w.header(g, wkbCurvePolygon, withSRID)
+		w.putUint32(uint32(len(g.Rings)))
+		for _, ring := range g.Rings {
+			w.geometry(ring, false)
+		}
+
+	case *MultiCurve:
+		w.header(g, wkbMultiCurve, withSRID)
+		w.putUint32(uint32(len(g.Curves)))
+		for _, curve := range g.Curves {
+			w.geometry(curve, false)
+		}
+
+	case *MultiSurface:
+		w.header(g, wkbMultiSurface, withSRID)
+		w.putUint32(uint32(len(g.Surfaces)))
+		for _, surface := range g.Surfaces {
+			w.geometry(surface, false)
+		}
+
+	case *GeometryCollection:
+		w.header(g, wkbGeometryCollection, withSRID)
+		w.putUint32(uint32(len(g.Geometries)))
+		for _, child := range g.Geometries {
+			w.geometry(child, false)
+		}
+
+	default:
+		if w.err == nil {
+			w.err = fmt.Errorf("wktparse: cannot marshal %T", g)
+		}
+	}
+}
+
+func (w *wkbWriter) rings(layout Layout, rings [][]Coordinate) {
+	w.putUint32(uint32(len(rings)))
+	for _, ring := range rings {
+		w.coordinates(layout, ring)
+	}
+}
+
+func (w *wkbWriter) coordinates(layout Layout, coordinates []Coordinate) {
+	w.putUint32(uint32(len(coordinates)))
+	for _, c := range coordinates {
+		w.coordinate(layout, c)
+	}
+}
+
+func (w *wkbWriter) coordinate(layout Layout, c Coordinate) {
+	for _, v := range layout.Ordinates(c) {
+		w.putFloat64(v)
+	}
+}
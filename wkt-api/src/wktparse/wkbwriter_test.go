@@ -0,0 +1,208 @@
+package wktparse
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Golden encodings of the same geometries as PostGIS writes them with
+// ST_AsBinary, ST_AsEWKB and ST_AsHEXEWKB, little endian (NDR) and big
+// endian (XDR).
+var isoNDRGolden = []struct {
+	wkt string
+	hex string
+}{
+	{"SRID=4326;POINT (1 2)", "0101000000000000000000F03F0000000000000040"},
+	{"POINT Z (1 2 3)", "01E9030000000000000000F03F00000000000000400000000000000840"},
+	{"POINT M (1 2 3)", "01D1070000000000000000F03F00000000000000400000000000000840"},
+	{"SRID=3857;POINT ZM (1 2 3 4)", "01B90B0000000000000000F03F000000000000004000000000000008400000000000001040"},
+	{"POINT EMPTY", "0101000000000000000000F87F000000000000F87F"},
+	{"POINT Z EMPTY", "01E9030000000000000000F87F000000000000F87F000000000000F87F"},
+	{"LINESTRING (30 10, 10 30, 40 40)", "0102000000030000000000000000003E40000000000000244000000000000024400000000000003E4000000000000044400000000000004440"},
+	{"SRID=4326;POLYGON ((0 0, 1 0, 1 1, 0 0))", "0103000000010000000400000000000000000000000000000000000000000000000000F03F0000000000000000000000000000F03F000000000000F03F00000000000000000000000000000000"},
+	{"MULTIPOINT Z ((10 40 1), EMPTY)", "01EC0300000200000001E903000000000000000024400000000000004440000000000000F03F01E9030000000000000000F87F000000000000F87F000000000000F87F"},
+	{"SRID=4326;MULTIPOLYGON M (((0 0 1, 1 0 1, 1 1 1, 0 0 1)))", "01D60700000100000001D3070000010000000400000000000000000000000000000000000000000000000000F03F000000000000F03F0000000000000000000000000000F03F000000000000F03F000000000000F03F000000000000F03F00000000000000000000000000000000000000000000F03F"},
+	{"GEOMETRYCOLLECTION (POINT (1 2), LINESTRING Z (1 2 3, 4 5 6), GEOMETRYCOLLECTION EMPTY)", "0107000000030000000101000000000000000000F03F000000000000004001EA03000002000000000000000000F03F00000000000000400000000000000840000000000000104000000000000014400000000000001840010700000000000000"},
+	{"COMPOUNDCURVE (CIRCULARSTRING (0 0, 1 1, 2 0), (2 0, 4 0))", "01090000000200000001080000000300000000000000000000000000000000000000000000000000F03F000000000000F03F000000000000004000000000000000000102000000020000000000000000000040000000000000000000000000000010400000000000000000"},
+	{"TIN (((0 0, 1 0, 1 1, 0 0)))", "0110000000010000000111000000010000000400000000000000000000000000000000000000000000000000F03F0000000000000000000000000000F03F000000000000F03F00000000000000000000000000000000"},
+}
+
+var isoXDRGolden = []struct {
+	wkt string
+	hex string
+}{
+	{"SRID=4326;POINT (1 2)", "00000000013FF00000000000004000000000000000"},
+	{"POINT Z (1 2 3)", "00000003E93FF000000000000040000000000000004008000000000000"},
+	{"POINT M (1 2 3)", "00000007D13FF000000000000040000000000000004008000000000000"},
+	{"SRID=3857;POINT ZM (1 2 3 4)", "0000000BB93FF0000000000000400000000000000040080000000000004010000000000000"},
+	{"POINT EMPTY", "00000000017FF80000000000007FF8000000000000"},
+	{"POINT Z EMPTY", "00000003E97FF80000000000007FF80000000000007FF8000000000000"},
+	{"LINESTRING (30 10, 10 30, 40 40)", "000000000200000003403E00000000000040240000000000004024000000000000403E00000000000040440000000000004044000000000000"},
+	{"SRID=4326;POLYGON ((0 0, 1 0, 1 1, 0 0))", "00000000030000000100000004000000000000000000000000000000003FF000000000000000000000000000003FF00000000000003FF000000000000000000000000000000000000000000000"},
+	{"MULTIPOINT Z ((10 40 1), EMPTY)", "00000003EC0000000200000003E9402400000000000040440000000000003FF000000000000000000003E97FF80000000000007FF80000000000007FF8000000000000"},
+	{"SRID=4326;MULTIPOLYGON M (((0 0 1, 1 0 1, 1 1 1, 0 0 1)))", "00000007D60000000100000007D30000000100000004000000000000000000000000000000003FF00000000000003FF000000000000000000000000000003FF00000000000003FF00000000000003FF00000000000003FF0000000000000000000000000000000000000000000003FF0000000000000"},
+	{"GEOMETRYCOLLECTION (POINT (1 2), LINESTRING Z (1 2 3, 4 5 6), GEOMETRYCOLLECTION EMPTY)", "00000000070000000300000000013FF0000000000000400000000000000000000003EA000000023FF000000000000040000000000000004008000000000000401000000000000040140000000000004018000000000000000000000700000000"},
+	{"COMPOUNDCURVE (CIRCULARSTRING (0 0, 1 1, 2 0), (2 0, 4 0))", "000000000900000002000000000800000003000000000000000000000000000000003FF00000000000003FF0000000000000400000000000000000000000000000000000000002000000024000000000000000000000000000000040100000000000000000000000000000"},
+	{"TIN (((0 0, 1 0, 1 1, 0 0)))", "00000000100000000100000000110000000100000004000000000000000000000000000000003FF000000000000000000000000000003FF00000000000003FF000000000000000000000000000000000000000000000"},
+}
+
+var ewkbNDRGolden = []struct {
+	wkt string
+	hex string
+}{
+	{"SRID=4326;POINT (1 2)", "0101000020E6100000000000000000F03F0000000000000040"},
+	{"POINT Z (1 2 3)", "0101000080000000000000F03F00000000000000400000000000000840"},
+	{"POINT M (1 2 3)", "0101000040000000000000F03F00000000000000400000000000000840"},
+	{"SRID=3857;POINT ZM (1 2 3 4)", "01010000E0110F0000000000000000F03F000000000000004000000000000008400000000000001040"},
+	{"POINT EMPTY", "0101000000000000000000F87F000000000000F87F"},
+	{"POINT Z EMPTY", "0101000080000000000000F87F000000000000F87F000000000000F87F"},
+	{"LINESTRING (30 10, 10 30, 40 40)", "0102000000030000000000000000003E40000000000000244000000000000024400000000000003E4000000000000044400000000000004440"},
+	{"SRID=4326;POLYGON ((0 0, 1 0, 1 1, 0 0))", "0103000020E6100000010000000400000000000000000000000000000000000000000000000000F03F0000000000000000000000000000F03F000000000000F03F00000000000000000000000000000000"},
+	{"MULTIPOINT Z ((10 40 1), EMPTY)", "010400008002000000010100008000000000000024400000000000004440000000000000F03F0101000080000000000000F87F000000000000F87F000000000000F87F"},
+	{"SRID=4326;MULTIPOLYGON M (((0 0 1, 1 0 1, 1 1 1, 0 0 1)))", "0106000060E6100000010000000103000040010000000400000000000000000000000000000000000000000000000000F03F000000000000F03F0000000000000000000000000000F03F000000000000F03F000000000000F03F000000000000F03F00000000000000000000000000000000000000000000F03F"},
+	{"GEOMETRYCOLLECTION (POINT (1 2), LINESTRING Z (1 2 3, 4 5 6), GEOMETRYCOLLECTION EMPTY)", "0107000000030000000101000000000000000000F03F0000000000000040010200008002000000000000000000F03F00000000000000400000000000000840000000000000104000000000000014400000000000001840010700000000000000"},
+	{"COMPOUNDCURVE (CIRCULARSTRING (0 0, 1 1, 2 0), (2 0, 4 0))", "01090000000200000001080000000300000000000000000000000000000000000000000000000000F03F000000000000F03F000000000000004000000000000000000102000000020000000000000000000040000000000000000000000000000010400000000000000000"},
+	{"TIN (((0 0, 1 0, 1 1, 0 0)))", "0110000000010000000111000000010000000400000000000000000000000000000000000000000000000000F03F0000000000000000000000000000F03F000000000000F03F00000000000000000000000000000000"},
+}
+
+var ewkbXDRGolden = []struct {
+	wkt string
+	hex string
+}{
+	{"SRID=4326;POINT (1 2)", "0020000001000010E63FF00000000000004000000000000000"},
+	{"POINT Z (1 2 3)", "00800000013FF000000000000040000000000000004008000000000000"},
+	{"POINT M (1 2 3)", "00400000013FF000000000000040000000000000004008000000000000"},
+	{"SRID=3857;POINT ZM (1 2 3 4)", "00E000000100000F113FF0000000000000400000000000000040080000000000004010000000000000"},
+	{"POINT EMPTY", "00000000017FF80000000000007FF8000000000000"},
+	{"POINT Z EMPTY", "00800000017FF80000000000007FF80000000000007FF8000000000000"},
+	{"LINESTRING (30 10, 10 30, 40 40)", "000000000200000003403E00000000000040240000000000004024000000000000403E00000000000040440000000000004044000000000000"},
+	{"SRID=4326;POLYGON ((0 0, 1 0, 1 1, 0 0))", "0020000003000010E60000000100000004000000000000000000000000000000003FF000000000000000000000000000003FF00000000000003FF000000000000000000000000000000000000000000000"},
+	{"MULTIPOINT Z ((10 40 1), EMPTY)", "0080000004000000020080000001402400000000000040440000000000003FF000000000000000800000017FF80000000000007FF80000000000007FF8000000000000"},
+	{"SRID=4326;MULTIPOLYGON M (((0 0 1, 1 0 1, 1 1 1, 0 0 1)))", "0060000006000010E60000000100400000030000000100000004000000000000000000000000000000003FF00000000000003FF000000000000000000000000000003FF00000000000003FF00000000000003FF00000000000003FF0000000000000000000000000000000000000000000003FF0000000000000"},
+	{"GEOMETRYCOLLECTION (POINT (1 2), LINESTRING Z (1 2 3, 4 5 6), GEOMETRYCOLLECTION EMPTY)", "00000000070000000300000000013FF000000000000040000000000000000080000002000000023FF000000000000040000000000000004008000000000000401000000000000040140000000000004018000000000000000000000700000000"},
+	{"COMPOUNDCURVE (CIRCULARSTRING (0 0, 1 1, 2 0), (2 0, 4 0))", "000000000900000002000000000800000003000000000000000000000000000000003FF00000000000003FF0000000000000400000000000000000000000000000000000000002000000024000000000000000000000000000000040100000000000000000000000000000"},
+	{"TIN (((0 0, 1 0, 1 1, 0 0)))", "00000000100000000100000000110000000100000004000000000000000000000000000000003FF000000000000000000000000000003FF00000000000003FF000000000000000000000000000000000000000000000"},
+}
+
+func TestMarshalWKBGolden(t *testing.T) {
+
+	for _, test := range []struct {
+		order  binary.ByteOrder
+		golden []struct {
+			wkt string
+			hex string
+		}
+	}{{binary.LittleEndian, isoNDRGolden}, {binary.BigEndian, isoXDRGolden}} {
+
+		for _, fixture := range test.golden {
+			g, err := Parse(fixture.wkt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := MarshalWKB(g, test.order)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.ToUpper(hex.EncodeToString(data)); got != fixture.hex {
+				t.Error("Expected ", fixture.hex, " for ", fixture.wkt, " got ", got)
+			}
+		}
+	}
+
+}
+
+func TestMarshalEWKBGolden(t *testing.T) {
+
+	for _, test := range []struct {
+		order  binary.ByteOrder
+		golden []struct {
+			wkt string
+			hex string
+		}
+	}{{binary.LittleEndian, ewkbNDRGolden}, {binary.BigEndian, ewkbXDRGolden}} {
+
+		for _, fixture := range test.golden {
+			g, err := Parse(fixture.wkt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := HexEWKB(g, test.order)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != fixture.hex {
+				t.Error("Expected ", fixture.hex, " for ", fixture.wkt, " got ", got)
+			}
+
+			parsed, err := ParseWKB(mustDecodeHex(t, fixture.hex))
+			if err != nil {
+				t.Fatal(fixture.wkt, ": ", err)
+			}
+			if !reflect.DeepEqual(parsed, g) {
+				t.Error("Expected ", fixture.wkt, " got ", parsed)
+			}
+		}
+	}
+
+}
+
+func TestWKBRoundTrip(t *testing.T) {
+
+	for _, wkt := range roundTripFixtures {
+		for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+
+			g, err := Parse(wkt)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := MarshalEWKB(g, order)
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed, err := ParseWKB(data)
+			if err != nil {
+				t.Fatal(wkt, ": ", err)
+			}
+			if !reflect.DeepEqual(parsed, g) {
+				t.Error("Expected ", wkt, " to round trip through EWKB got ", parsed)
+			}
+
+			// ISO WKB has no SRID.
+			data, err = MarshalWKB(g, order)
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed, err = ParseWKB(data)
+			if err != nil {
+				t.Fatal(wkt, ": ", err)
+			}
+			g.SetSRID(0)
+			if !reflect.DeepEqual(parsed, g) {
+				t.Error("Expected ", wkt, " to round trip through WKB got ", parsed)
+			}
+		}
+	}
+
+}
+
+func TestMarshalWKBErrors(t *testing.T) {
+
+	if _, err := MarshalWKB(nil, binary.LittleEndian); err == nil {
+		t.Error("Expected an error for a nil geometry")
+	}
+
+	var order binary.ByteOrder
+	if _, err := MarshalEWKB(NewPoint(XY, Coordinate{X: 1, Y: 2}), order); err == nil {
+		t.Error("Expected an error for a nil byte order")
+	}
+
+	// ISO dimension offsets and EWKB flags cannot be mixed.
+	if _, err := ParseWKB(mustDecodeHex(t, "01E9030080000000000000F03F00000000000000400000000000000840")); err == nil {
+		t.Error("Expected an error for POINT Z with the EWKB Z flag as well")
+	}
+
+}
@@ -1,33 +1,22 @@
+// Package wktparse reads and writes geometries in the Well Known Text (WKT)
+// format. Parsing is done by a lexer (lexer.go) feeding a recursive-descent
+// parser (parser.go) that follows the OGC Simple Features WKT grammar. Parse
+// returns one of the typed geometries in geometry.go; ParseGeometry and the
+// per-type functions below return the older type string and CoordinateSet.
+// Marshal (writer.go) writes a typed geometry back out as WKT. ParseWKB
+// (wkb.go) decodes Well Known Binary and PostGIS EWKB into the same types,
+// and MarshalWKB and MarshalEWKB (wkbwriter.go) encode them. ParseGeoJSON
+// (geojson.go) and MarshalGeoJSON (geojsonwriter.go) do the same for
+// GeoJSON. A Decoder (decoder.go) parses a stream of WKT records one at a
+// time, and a Parser parses WKT held in byte slices while reusing its memory
+// between calls.
 package wktparse
 
-// LineString, LineString Z, LineString M, LineString ZM
-//     strip brackets
-//     split on comma
-//     x, y, z, m
-//
-// Polygon
-//     If ),( present
-//         Split on ),(
-//     else
-//         Split on comma
-//
-// MultiPoint
-//     Strip all brackets
-//     Split on comma
-//
-// MultiLineString
-//     Split on ),(
-//         Strip all brackets
-//         Split on comma
-//
-// TIN, TIN Z, TIN M, TIN ZM
-
 import (
+	"encoding/json"
 	"fmt"
 	"regexp"
-	"strconv"
 	"strings"
-  "reflect"
 )
 
 type Coordinate struct {
@@ -37,230 +26,290 @@ type Coordinate struct {
 	M float64
 }
 
+// CoordinateSet holds the coordinates of one geometry. Layout says which
+// ordinates they have; the others are always zero. When written as JSON each
+// coordinate only has the keys its layout has, so 2D data has no "Z".
 type CoordinateSet struct {
-	Coordinates []Coordinate //(0 0 0, 0 1 0, 1 1 0, 1 0 0, 0 0 0)
-	Holes       []Coordinate
+	Layout      Layout
+	Coordinates []Coordinate    //(0 0 0, 0 1 0, 1 1 0, 1 0 0, 0 0 0)
+	Rings       [][]Coordinate  // Rings of a POLYGON or TRIANGLE in order, the shell followed by any holes
+	Parts       []CoordinateSet // One set per member of a MULTILINESTRING or MULTIPOLYGON, or face of a TIN or POLYHEDRALSURFACE
+	Children    []WKT           // Members of a GEOMETRYCOLLECTION, each with its own type
 }
 
-// Define WTK
-type WKT struct {
-	WTKType    string
-	Geometries []CoordinateSet
+// Shell returns the outer ring of a polygon, or nil if it has no rings.
+func (c CoordinateSet) Shell() []Coordinate {
+	if len(c.Rings) == 0 {
+		return nil
+	}
+	return c.Rings[0]
 }
 
-func ParseGeometry(WKTString string) (string, CoordinateSet) {
-
-	var WKType string
-	var Geometries CoordinateSet
-
-	WKTString = strings.ToUpper(WKTString)
-
-	if strings.HasPrefix(WKTString, "POINT") {
-
-		WKType, Geometries = Point(WKTString, "POINT")
-
-	} else if strings.HasPrefix(WKTString, "LINESTRING") {
-
-		WKType, Geometries = Line(WKTString, "LINESTRING")
-
-	} else if strings.HasPrefix(WKTString, "POLYGON") {
-
-		WKType, Geometries = Polygon(WKTString, "POLYGON")
-
-	} else if strings.HasPrefix(WKTString, "MULTIPOINT") {
-
-	//WKType, Geometries = Multipoint(WKTString, "MULTIPOINT")
-
-		//Geometries = Point(WKTString)
-	} else if strings.HasPrefix(WKTString, "MULTILINESTRING") {
-		//Geometries = Point(WKTString)
-	} else if strings.HasPrefix(WKTString, "MULTIPOLYGON") {
-		//Geometries = Point(WKTString)
+// Holes returns the inner rings of a polygon.
+func (c CoordinateSet) Holes() [][]Coordinate {
+	if len(c.Rings) < 2 {
+		return nil
 	}
-
-	return WKType, Geometries
-
+	return c.Rings[1:]
 }
 
-// POINT, POINT M, POINT Z, POINT ZM
-// POINT (6 10)
-func Point(WKTString string, ParentType string) (string, CoordinateSet) {
-
-	coordinates := CoordinateSet{}
-	wkttype := GetGeometryType(WKTString, ParentType)
-
-	if IsNotEmpty(WKTString) {
+// jsonCoordinate writes a coordinate with only the ordinates of its layout.
+type jsonCoordinate struct {
+	X float64
+	Y float64
+	Z *float64 `json:",omitempty"`
+	M *float64 `json:",omitempty"`
+}
 
-		point := strings.Split(RemoveWrappingGeom(WKTString), " ")
-		var coords []float64
-		for _, c := range point {
-			if f, err := strconv.ParseFloat(c, 64); err == nil {
-				coords = append(coords, f)
-			}
+func (c CoordinateSet) jsonCoordinates(coordinates []Coordinate) []jsonCoordinate {
+	if coordinates == nil {
+		return nil
+	}
+	out := make([]jsonCoordinate, len(coordinates))
+	for i := range coordinates {
+		out[i] = jsonCoordinate{X: coordinates[i].X, Y: coordinates[i].Y}
+		if c.Layout.HasZ() {
+			out[i].Z = &coordinates[i].Z
+		}
+		if c.Layout.HasM() {
+			out[i].M = &coordinates[i].M
 		}
-
-		coordinate := GetCoordinate(coords, wkttype)
-		coordinates = CoordinateSet{Coordinates: []Coordinate{coordinate}}
-
 	}
-
-	return wkttype, coordinates
+	return out
 }
 
-//LINESTRING (30 10, 10 30, 40 40)
-func Line(WKTString string, ParentType string) (string, CoordinateSet) {
-
-	coordinateset := CoordinateSet{}
-	wkttype := GetGeometryType(WKTString, ParentType)
-
-	if IsNotEmpty(WKTString) {
-
-		lineparts := strings.Split(RemoveWrappingGeom(WKTString), ",")
-
-		// For each part of the line
-		for _, l := range lineparts {
-
-			l = strings.TrimSpace(l)
-			line := strings.Split(l, " ")
-			var coords []float64
-
-			// For each coordinate in the line
-			for _, c := range line {
-				c = strings.TrimSpace(c)
-				if c != " " || c != "" {
-					if f, err := strconv.ParseFloat(c, 64); err == nil {
-						coords = append(coords, f)
-					} else {
-						fmt.Print("Error converting ", c, "to float64", " ", reflect.TypeOf(c), "\n ")
-					}
-				}
-			}
-
-			if (len(coords) > 0) {
-
-				coordinateset.Coordinates = append(coordinateset.Coordinates, GetCoordinate(coords, wkttype))
-
-			} else {
-
-				fmt.Print("Not enough coordinates in this line :", WKTString)
-
-			}
+// MarshalJSON writes the set with the same keys as its fields, but leaves
+// out any Z or M ordinate that its Layout does not have.
+func (c CoordinateSet) MarshalJSON() ([]byte, error) {
 
+	var rings [][]jsonCoordinate
+	if c.Rings != nil {
+		rings = make([][]jsonCoordinate, len(c.Rings))
+		for i, ring := range c.Rings {
+			rings[i] = c.jsonCoordinates(ring)
 		}
-
 	}
 
-	return wkttype, coordinateset
+	return json.Marshal(struct {
+		Layout      Layout
+		Coordinates []jsonCoordinate
+		Rings       [][]jsonCoordinate
+		Parts       []CoordinateSet
+		Children    []WKT
+	}{c.Layout, c.jsonCoordinates(c.Coordinates), rings, c.Parts, c.Children})
 }
 
-//POLYGON ((35 10, 45 45, 15 40, 10 20, 35 10), (20 30, 35 35, 30 20, 20 30))
-func Polygon(WKTString string, ParentType string) (string, CoordinateSet) {
-
-	coordinateset := CoordinateSet{}
-	wkttype := GetGeometryType(WKTString, ParentType)
-
-	if IsNotEmpty(WKTString) {
+// Define WTK. As a member of a GEOMETRYCOLLECTION it holds a single
+// CoordinateSet in Geometries.
+type WKT struct {
+	WTKType    string
+	Geometries []CoordinateSet
+}
 
-		polygonpartsstr := RemoveWrappingGeom(WKTString)
-		polygonparts := []string{}
+// Parse parses a WKT string into one of the geometry types in this package.
+// If the string is not valid WKT the error is a *ParseError.
+func Parse(WKTString string) (Geometry, error) {
+	return parse([]byte(WKTString), "")
+}
 
-		if strings.Contains(polygonpartsstr, "),(") {
+// ParseBytes is Parse for WKT held in a byte slice, which it reads in place
+// without copying it into a string first.
+func ParseBytes(data []byte) (Geometry, error) {
+	return parse(data, "")
+}
 
-			polygonparts = strings.Split(polygonpartsstr, "),(") // We remove other brackets later
+// A Parser parses many WKT strings one after another, as in a bulk load, and
+// reuses the memory that holds their coordinates and rings from one call to
+// the next. Once it has grown to fit the largest input, parsing only
+// allocates the geometry structs themselves. A Parser is not safe for
+// concurrent use.
+type Parser struct {
+	p parser
+}
 
-		} else {
+// NewParser returns a Parser that stores coordinates in buf, growing it as
+// needed. buf may be nil.
+func NewParser(buf []Coordinate) *Parser {
+	return &Parser{p: parser{coordinates: buf[:0]}}
+}
 
-			polygonparts = []string{ polygonpartsstr }
+// Parse parses data like ParseBytes. The geometry it returns shares memory
+// with the Parser and is only valid until the next call, so a caller that
+// keeps it must copy it first, for example by writing it out with
+// MarshalWKB and reading it back.
+func (p *Parser) Parse(data []byte) (Geometry, error) {
+	return p.p.parse(data, "")
+}
 
-		}
+// ParseGeometry parses a WKT string and returns its geometry type, including
+// any dimension tag (e.g. "LINESTRING Z"), and its coordinates. If the string
+// is not valid WKT the error is a *ParseError.
+func ParseGeometry(WKTString string) (string, CoordinateSet, error) {
+	return toCoordinateSet(parse([]byte(WKTString), ""))
+}
 
-		// For each part of the polgon
-		for _, part := range polygonparts {
+// POINT, POINT M, POINT Z, POINT ZM
+// POINT (6 10)
+func ParsePoint(WKTString string, ParentType string) (string, CoordinateSet, error) {
+	return toCoordinateSet(parse([]byte(WKTString), ParentType))
+}
 
-			part = RemoveWrappingGeom(part)
-			partslice := strings.Split(part, ",")
+// LINESTRING (30 10, 10 30, 40 40)
+func Line(WKTString string, ParentType string) (string, CoordinateSet, error) {
+	return toCoordinateSet(parse([]byte(WKTString), ParentType))
+}
 
-			for _, coordstr := range partslice {
+// POLYGON, POLYGON M, POLYGON Z, POLYGON ZM
+// POLYGON ((35 10, 45 45, 15 40, 10 20, 35 10), (20 30, 35 35, 30 20, 20 30))
+func ParsePolygon(WKTString string, ParentType string) (string, CoordinateSet, error) {
+	return toCoordinateSet(parse([]byte(WKTString), ParentType))
+}
 
-				coordstr = strings.TrimSpace(coordstr)
-				coordstr = RemoveAllBrackets(coordstr)
-				line := strings.Split(coordstr, " ")
-				var coords []float64
+// MULTIPOINT, MULTIPOINT M, MULTIPOINT Z, MULTIPOINT ZM
+// MULTIPOINT ((10 40), (40 30), (20 20), (30 10)) or MULTIPOINT (10 40, 40 30)
+func Multipoint(WKTString string, ParentType string) (string, CoordinateSet, error) {
+	return toCoordinateSet(parse([]byte(WKTString), ParentType))
+}
 
-				// For each coordinate in the line
-				for _, c := range line {
-					c = strings.TrimSpace(c)
-					if c != " " || c != "" {
-						if f, err := strconv.ParseFloat(c, 64); err == nil {
-							coords = append(coords, f)
-						} else {
-							fmt.Print("Error converting ", c, "to float64", " ", reflect.TypeOf(c), "\n ")
-						}
-					}
-				}
+// MULTILINESTRING, MULTILINESTRING M, MULTILINESTRING Z, MULTILINESTRING ZM
+// MULTILINESTRING ((10 10, 20 20, 10 40), (40 40, 30 30, 40 20, 30 10))
+func Multilinestring(WKTString string, ParentType string) (string, CoordinateSet, error) {
+	return toCoordinateSet(parse([]byte(WKTString), ParentType))
+}
 
-				if (len(coords) > 0 && len(coordinateset.Coordinates) < 1) {
+// MULTIPOLYGON, MULTIPOLYGON M, MULTIPOLYGON Z, MULTIPOLYGON ZM
+// MULTIPOLYGON (((40 40, 20 45, 45 30, 40 40)), ((20 35, 10 30, 10 10, 30 5, 45 20, 20 35), (30 20, 20 15, 20 25, 30 20)))
+func Multipolygon(WKTString string, ParentType string) (string, CoordinateSet, error) {
+	return toCoordinateSet(parse([]byte(WKTString), ParentType))
+}
 
-					coordinateset.Coordinates = append(coordinateset.Coordinates, GetCoordinate(coords, wkttype))
+// GEOMETRYCOLLECTION, GEOMETRYCOLLECTION M, GEOMETRYCOLLECTION Z, GEOMETRYCOLLECTION ZM
+// GEOMETRYCOLLECTION (POINT (4 6), LINESTRING (4 6, 7 10))
+func Geometrycollection(WKTString string, ParentType string) (string, CoordinateSet, error) {
+	return toCoordinateSet(parse([]byte(WKTString), ParentType))
+}
 
-				} else if (len(coords) > 0 && len(coordinateset.Coordinates) >= 1) {
+func toCoordinateSet(g Geometry, err error) (string, CoordinateSet, error) {
+	if err != nil {
+		return "", CoordinateSet{}, err
+	}
+	wkttype, set := ToCoordinateSet(g)
+	return wkttype, set, nil
+}
 
-					coordinateset.Holes =  append(coordinateset.Holes, GetCoordinate(coords, wkttype))
+// ToCoordinateSet converts a geometry into the type string and CoordinateSet
+// that ParseGeometry returns for it. EMPTY members of a MULTIPOINT have no
+// coordinate and are left out.
+func ToCoordinateSet(g Geometry) (string, CoordinateSet) {
 
-				} else {
-					fmt.Print("Not enough coordinates in this line :", WKTString)
-				}
+	set := CoordinateSet{Layout: g.Layout()}
 
+	switch g := g.(type) {
+	case *Point:
+		if !g.IsEmpty() {
+			set.Coordinates = []Coordinate{g.Coordinate}
+		}
+	case *LineString:
+		set.Coordinates = g.Coordinates
+	case *CircularString:
+		set.Coordinates = g.Coordinates
+	case *Polygon:
+		set.Rings = g.Rings
+	case *Triangle:
+		set.Rings = g.Rings
+	case *MultiPoint:
+		for _, point := range g.Points {
+			if !point.IsEmpty() {
+				set.Coordinates = append(set.Coordinates, point.Coordinate)
 			}
-
+		}
+	case *MultiLineString:
+		for _, line := range g.LineStrings {
+			set.Parts = append(set.Parts, CoordinateSet{Layout: line.Layout(), Coordinates: line.Coordinates})
+		}
+	case *MultiPolygon:
+		for _, polygon := range g.Polygons {
+			set.Parts = append(set.Parts, CoordinateSet{Layout: polygon.Layout(), Rings: polygon.Rings})
+		}
+	case *PolyhedralSurface:
+		for _, polygon := range g.Polygons {
+			set.Parts = append(set.Parts, CoordinateSet{Layout: polygon.Layout(), Rings: polygon.Rings})
+		}
+	case *TIN:
+		for _, triangle := range g.Triangles {
+			set.Parts = append(set.Parts, CoordinateSet{Layout: triangle.Layout(), Rings: triangle.Rings})
+		}
+	case *CompoundCurve:
+		for _, segment := range g.Segments {
+			set.Children = append(set.Children, toWKT(segment))
+		}
+	case *CurvePolygon:
+		for _, ring := range g.Rings {
+			set.Children = append(set.Children, toWKT(ring))
+		}
+	case *MultiCurve:
+		for _, curve := range g.Curves {
+			set.Children = append(set.Children, toWKT(curve))
+		}
+	case *MultiSurface:
+		for _, surface := range g.Surfaces {
+			set.Children = append(set.Children, toWKT(surface))
+		}
+	case *GeometryCollection:
+		for _, child := range g.Geometries {
+			set.Children = append(set.Children, toWKT(child))
 		}
 	}
 
-	return wkttype, coordinateset
+	return typeName(g.Type(), g.Layout().Tag()), set
+}
 
+func toWKT(g Geometry) WKT {
+	wkttype, set := ToCoordinateSet(g)
+	return WKT{WTKType: wkttype, Geometries: []CoordinateSet{set}}
 }
 
-func GetCoordinate(coords []float64, wkttype string) Coordinate {
+// GetCoordinate builds a Coordinate from the ordinates of one point of a
+// geometry of the given type, e.g. "POINT ZM". It returns an error if coords
+// holds fewer ordinates than the dimension of wkttype requires.
+func GetCoordinate(coords []float64, wkttype string) (Coordinate, error) {
 
-	var coordinate Coordinate
+	_, dim := splitType(wkttype)
 
-	if strings.Contains(wkttype, "Z") && !strings.Contains(wkttype, "ZM") {
-
-		coordinate = Coordinate{X: coords[0], Y: coords[1], Z: coords[2]}
+	if stride := layoutFromTag(dim).Stride(); len(coords) < stride {
+		return Coordinate{}, fmt.Errorf("wktparse: %s needs %d ordinates per coordinate, got %d", wkttype, stride, len(coords))
+	}
 
-	} else if strings.Contains(wkttype, "ZM") {
+	var coordinate Coordinate
 
+	switch dim {
+	case "Z":
+		coordinate = Coordinate{X: coords[0], Y: coords[1], Z: coords[2]}
+	case "ZM":
 		coordinate = Coordinate{X: coords[0], Y: coords[1], Z: coords[2], M: coords[3]}
-
-	} else if strings.Contains(wkttype, "M") {
-
+	case "M":
 		coordinate = Coordinate{X: coords[0], Y: coords[1], M: coords[2]}
-
-	} else {
-
+	default:
 		coordinate = Coordinate{X: coords[0], Y: coords[1]}
-
 	}
 
-	return coordinate
+	return coordinate, nil
 
 }
 
-
-
 func GetGeometryType(WKTString string, ParentType string) string {
 
 	var wkttype string
 
-	if strings.HasPrefix(WKTString, ParentType + " Z") && !strings.HasPrefix(WKTString, ParentType + " ZM") {
+	if strings.HasPrefix(WKTString, ParentType+" Z") && !strings.HasPrefix(WKTString, ParentType+" ZM") {
 
 		wkttype = ParentType + " Z"
 
-	} else if strings.HasPrefix(WKTString, ParentType + " M") {
+	} else if strings.HasPrefix(WKTString, ParentType+" M") {
 
 		wkttype = ParentType + " M"
 
-	} else if strings.HasPrefix(WKTString, ParentType + " ZM") {
+	} else if strings.HasPrefix(WKTString, ParentType+" ZM") {
 
 		wkttype = ParentType + " ZM"
 
@@ -276,15 +325,14 @@ func GetGeometryType(WKTString string, ParentType string) string {
 
 func IsNotEmpty(str string) bool {
 
-		if strings.Contains(str, "EMPTY")  {
-			return false
-		} else {
-			return true
-		}
+	if strings.Contains(str, "EMPTY") {
+		return false
+	} else {
+		return true
+	}
 
 }
 
-
 func RemoveWrappingGeom(str string) string {
 
 	if strings.Contains(str, "(") && strings.Contains(str, ")") {
@@ -295,10 +343,11 @@ func RemoveWrappingGeom(str string) string {
 
 }
 
+var alphabet = regexp.MustCompile("[A-Za-z]")
+
 func RemoveAllAlphabet(str string) string {
 
-	reg, _ := regexp.Compile("[A-Za-z]")
-	return reg.ReplaceAllString(str, "")
+	return alphabet.ReplaceAllString(str, "")
 
 }
 
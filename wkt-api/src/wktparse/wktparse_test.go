@@ -6,6 +6,7 @@ import "strings"
 //import "log"
 import "encoding/json"
 import "os"
+import "reflect"
 
 func TestRemoveWrappingGeom(t *testing.T) {
 	var wktone string
@@ -54,7 +55,10 @@ func TestRemoveAllAlphabet(t *testing.T) {
 
 func TestIsNotEmpty(t *testing.T) {
 	var point string = "POINT EMPTY"
-	pointtype, pointwkt := ParseGeometry(point)
+	pointtype, pointwkt, err := ParseGeometry(point)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if pointtype != "POINT" {
 		t.Error("Expected POINT got", pointtype)
@@ -69,7 +73,10 @@ func TestIsNotEmpty(t *testing.T) {
 // Test Point , Point Z, Point M, Point ZM
 func TestPoint(t *testing.T) {
 	var point string = "POINT(123.45 543.21)"
-	pointtype, pointwkt := ParseGeometry(point)
+	pointtype, pointwkt, err := ParseGeometry(point)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if strings.HasPrefix(point, "POINT") == false {
 		t.Error("String was not prefixed with POINT")
@@ -92,7 +99,10 @@ func TestPoint(t *testing.T) {
 
 func TestPointZ(t *testing.T) {
 	var pointz string = "POINT Z(123.45 543.21 65.6)"
-	pointztype, pointzwkt := ParseGeometry(pointz)
+	pointztype, pointzwkt, err := ParseGeometry(pointz)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if strings.HasPrefix(pointz, "POINT Z") == false {
 		t.Error("String was not prefixed with POINT Z")
@@ -119,7 +129,10 @@ func TestPointZ(t *testing.T) {
 
 func TestPointZM(t *testing.T) {
 	var pointzm string = "POINT ZM(123.45 543.21 65.6 100.0)"
-	pointzmtype, pointzmwkt := ParseGeometry(pointzm)
+	pointzmtype, pointzmwkt, err := ParseGeometry(pointzm)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if strings.HasPrefix(pointzm, "POINT ZM") == false {
 		t.Error("String was not prefixed with POINT ZM")
@@ -147,10 +160,136 @@ func TestPointZM(t *testing.T) {
 
 }
 
+func TestMultiPoint(t *testing.T) {
+	var multipoint string = "MULTIPOINT (10 40, 40 30, 20 20, 30 10)"
+	multipointtype, multipointwkt, err := ParseGeometry(multipoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if multipointtype != "MULTIPOINT" {
+		t.Error("Expected MULTIPOINT got", multipointtype)
+	}
+
+	if len(multipointwkt.Coordinates) != 4 {
+		t.Error("MultiPoint does not have 4 points, has ", strconv.Itoa(len(multipointwkt.Coordinates)))
+	} else {
+		if multipointwkt.Coordinates[1].X != 40 {
+			t.Error("Expected X to be 40 got ", multipointwkt.Coordinates[1].X)
+		}
+		if multipointwkt.Coordinates[3].Y != 10 {
+			t.Error("Expected Y to be 10 got ", multipointwkt.Coordinates[3].Y)
+		}
+	}
+}
+
+func TestMultiPointBracketed(t *testing.T) {
+	var multipoint string = "MULTIPOINT ((10 40), (40 30), (20 20), (30 10))"
+	multipointtype, multipointwkt, err := ParseGeometry(multipoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if multipointtype != "MULTIPOINT" {
+		t.Error("Expected MULTIPOINT got", multipointtype)
+	}
+
+	if len(multipointwkt.Coordinates) != 4 {
+		t.Error("MultiPoint does not have 4 points, has ", strconv.Itoa(len(multipointwkt.Coordinates)))
+	} else {
+		if multipointwkt.Coordinates[2].X != 20 {
+			t.Error("Expected X to be 20 got ", multipointwkt.Coordinates[2].X)
+		}
+	}
+}
+
+func TestMultiPointZ(t *testing.T) {
+	var multipoint string = "MULTIPOINT Z ((10 40 1), (40 30 2))"
+	multipointtype, multipointwkt, err := ParseGeometry(multipoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if multipointtype != "MULTIPOINT Z" {
+		t.Error("Expected MULTIPOINT Z got", multipointtype)
+	}
+
+	if len(multipointwkt.Coordinates) != 2 {
+		t.Error("MultiPoint does not have 2 points, has ", strconv.Itoa(len(multipointwkt.Coordinates)))
+	} else {
+		if multipointwkt.Coordinates[1].Z != 2 {
+			t.Error("Expected Z to be 2 got ", multipointwkt.Coordinates[1].Z)
+		}
+	}
+}
+
+func TestMultiPointM(t *testing.T) {
+	var multipoint string = "MULTIPOINT M (10 40 7, 40 30 8)"
+	multipointtype, multipointwkt, err := ParseGeometry(multipoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if multipointtype != "MULTIPOINT M" {
+		t.Error("Expected MULTIPOINT M got", multipointtype)
+	}
+
+	if len(multipointwkt.Coordinates) != 2 {
+		t.Error("MultiPoint does not have 2 points, has ", strconv.Itoa(len(multipointwkt.Coordinates)))
+	} else {
+		if multipointwkt.Coordinates[0].M != 7 {
+			t.Error("Expected M to be 7 got ", multipointwkt.Coordinates[0].M)
+		}
+		if multipointwkt.Coordinates[0].Z != 0 {
+			t.Error("Expected Z to be 0 got ", multipointwkt.Coordinates[0].Z)
+		}
+	}
+}
+
+func TestMultiPointZM(t *testing.T) {
+	var multipoint string = "MULTIPOINT ZM ((10 40 1 7), EMPTY, (40 30 2 8))"
+	multipointtype, multipointwkt, err := ParseGeometry(multipoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if multipointtype != "MULTIPOINT ZM" {
+		t.Error("Expected MULTIPOINT ZM got", multipointtype)
+	}
+
+	if len(multipointwkt.Coordinates) != 2 {
+		t.Error("MultiPoint does not have 2 points, has ", strconv.Itoa(len(multipointwkt.Coordinates)))
+	} else {
+		if multipointwkt.Coordinates[1].Z != 2 {
+			t.Error("Expected Z to be 2 got ", multipointwkt.Coordinates[1].Z)
+		}
+		if multipointwkt.Coordinates[1].M != 8 {
+			t.Error("Expected M to be 8 got ", multipointwkt.Coordinates[1].M)
+		}
+	}
+}
+
+func TestMultiPointEmpty(t *testing.T) {
+	multipointtype, multipointwkt, err := Multipoint("MULTIPOINT EMPTY", "MULTIPOINT")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if multipointtype != "MULTIPOINT" {
+		t.Error("Expected MULTIPOINT got", multipointtype)
+	}
+	if len(multipointwkt.Coordinates) != 0 {
+		t.Error("MultiPoint should not have any coordinates as it is empty, has ", strconv.Itoa(len(multipointwkt.Coordinates)))
+	}
+}
+
 func TestLine(t *testing.T) {
 
 	var line string = "LINESTRING (30 10, 10 30, 40 40)"
-	linetype, linewkt := ParseGeometry(line)
+	linetype, linewkt, err := ParseGeometry(line)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if strings.HasPrefix(line, "LINESTRING") == false {
 		t.Error("String was not prefixed with LINESTRING")
@@ -175,7 +314,10 @@ func TestLine(t *testing.T) {
 func TestLineZ(t *testing.T) {
 
 	var line string = "LINESTRING Z (30 10 5, 10 30 5, 40 40 5)"
-	linetype, linewkt := ParseGeometry(line)
+	linetype, linewkt, err := ParseGeometry(line)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if strings.HasPrefix(line, "LINESTRING Z") == false {
 		t.Error("String was not prefixed with LINESTRING Z")
@@ -203,7 +345,10 @@ func TestLineZ(t *testing.T) {
 func TestLineM(t *testing.T) {
 
 	var line string = "LINESTRING M (30 10 10, 10 30 9, 40 40 8)"
-	linetype, linewkt := ParseGeometry(line)
+	linetype, linewkt, err := ParseGeometry(line)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if strings.HasPrefix(line, "LINESTRING M") == false {
 		t.Error("String was not prefixed with LINESTRING M")
@@ -234,7 +379,10 @@ func TestLineM(t *testing.T) {
 func TestLineZM(t *testing.T) {
 
 	var line string = "LINESTRING ZM (30 10 5 10, 10 30 5 9, 40 40 5 8)"
-	linetype, linewkt := ParseGeometry(line)
+	linetype, linewkt, err := ParseGeometry(line)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if strings.HasPrefix(line, "LINESTRING ZM") == false {
 		t.Error("String was not prefixed with LINESTRING ZM")
@@ -263,11 +411,115 @@ func TestLineZM(t *testing.T) {
 }
 
 
+func TestMultiLine(t *testing.T) {
+
+	var multiline string = "MULTILINESTRING ((10 10, 20 20, 10 40), (40 40, 30 30, 40 20, 30 10))"
+	multilinetype, multilinewkt, err := ParseGeometry(multiline)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if multilinetype != "MULTILINESTRING" {
+		t.Error("Expected MULTILINESTRING got ", multilinetype)
+	}
+
+	if len(multilinewkt.Coordinates) != 0 {
+		t.Error("MultiLineString should keep its lines in Parts, has ", strconv.Itoa(len(multilinewkt.Coordinates)), " coordinates")
+	}
+
+	if len(multilinewkt.Parts) != 2 {
+		t.Error("MultiLineString does not have 2 lines, has ", strconv.Itoa(len(multilinewkt.Parts)))
+	} else {
+		if len(multilinewkt.Parts[0].Coordinates) != 3 {
+			t.Error("Expected first line to have 3 coordinates got ", strconv.Itoa(len(multilinewkt.Parts[0].Coordinates)))
+		}
+		if len(multilinewkt.Parts[1].Coordinates) != 4 {
+			t.Error("Expected second line to have 4 coordinates got ", strconv.Itoa(len(multilinewkt.Parts[1].Coordinates)))
+		} else if multilinewkt.Parts[1].Coordinates[0].X != 40.0 {
+			t.Error("Expected X to be 40.0 got ", multilinewkt.Parts[1].Coordinates[0].X)
+		}
+	}
+
+}
+
+func TestMultiLineZ(t *testing.T) {
+
+	var multiline string = "MULTILINESTRING Z ((10 10 1, 20 20 2), (40 40 3, 30 30 4))"
+	multilinetype, multilinewkt, err := ParseGeometry(multiline)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if multilinetype != "MULTILINESTRING Z" {
+		t.Error("Expected MULTILINESTRING Z got ", multilinetype)
+	}
+
+	if len(multilinewkt.Parts) != 2 {
+		t.Error("MultiLineString does not have 2 lines, has ", strconv.Itoa(len(multilinewkt.Parts)))
+	} else if multilinewkt.Parts[1].Coordinates[1].Z != 4.0 {
+		t.Error("Expected Z to be 4.0 got ", multilinewkt.Parts[1].Coordinates[1].Z)
+	}
+
+}
+
+func TestMultiLineM(t *testing.T) {
+
+	var multiline string = "MULTILINESTRING M ((10 10 1, 20 20 2), EMPTY)"
+	multilinetype, multilinewkt, err := ParseGeometry(multiline)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if multilinetype != "MULTILINESTRING M" {
+		t.Error("Expected MULTILINESTRING M got ", multilinetype)
+	}
+
+	if len(multilinewkt.Parts) != 2 {
+		t.Error("MultiLineString does not have 2 lines, has ", strconv.Itoa(len(multilinewkt.Parts)))
+	} else {
+		if multilinewkt.Parts[0].Coordinates[1].M != 2.0 {
+			t.Error("Expected M to be 2.0 got ", multilinewkt.Parts[0].Coordinates[1].M)
+		}
+		if len(multilinewkt.Parts[1].Coordinates) != 0 {
+			t.Error("Expected second line to be empty got ", strconv.Itoa(len(multilinewkt.Parts[1].Coordinates)))
+		}
+	}
+
+}
+
+func TestMultiLineZM(t *testing.T) {
+
+	var multiline string = "MULTILINESTRING ZM ((10 10 1 5, 20 20 2 6))"
+	multilinetype, multilinewkt, err := Multilinestring(multiline, "MULTILINESTRING")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if multilinetype != "MULTILINESTRING ZM" {
+		t.Error("Expected MULTILINESTRING ZM got ", multilinetype)
+	}
+
+	if len(multilinewkt.Parts) != 1 {
+		t.Error("MultiLineString does not have 1 line, has ", strconv.Itoa(len(multilinewkt.Parts)))
+	} else {
+		if multilinewkt.Parts[0].Coordinates[0].Z != 1.0 {
+			t.Error("Expected Z to be 1.0 got ", multilinewkt.Parts[0].Coordinates[0].Z)
+		}
+		if multilinewkt.Parts[0].Coordinates[0].M != 5.0 {
+			t.Error("Expected M to be 5.0 got ", multilinewkt.Parts[0].Coordinates[0].M)
+		}
+	}
+
+}
+
 func TestPolygonHoles(t *testing.T) {
 
 	var polygon string = "POLYGON ((35 10, 45 45, 15 40, 10 20, 35 10),(20 30, 35 35, 30 20, 20 30))"
 
-	polygontype, polygonwkt := ParseGeometry(polygon)
+	polygontype, polygonwkt, err := ParseGeometry(polygon)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if strings.HasPrefix(polygon, "POLYGON") == false {
 		t.Error("String was not prefixed with POLYGON")
@@ -276,14 +528,17 @@ func TestPolygonHoles(t *testing.T) {
 		t.Error("Expected POLYGON got ", polygontype)
 	}
 
-	if len(polygonwkt.Coordinates) < 1 {
-		t.Error("Polygon does not have at least 1 set of coordinates, has ", strconv.Itoa(len(polygonwkt.Coordinates)))
+	if len(polygonwkt.Rings) != 2 {
+		t.Error("Polygon does not have a shell and 1 hole, has ", strconv.Itoa(len(polygonwkt.Rings)), " rings")
 	} else {
-		if polygonwkt.Coordinates[0].X != 35.0 {
-			t.Error("Expected X to be 35.0 got ", polygonwkt.Coordinates[0].X)
+		if polygonwkt.Shell()[0].X != 35.0 {
+			t.Error("Expected X to be 35.0 got ", polygonwkt.Shell()[0].X)
+		}
+		if polygonwkt.Shell()[0].Y != 10.0 {
+			t.Error("Expected Y to be 10.0 got ", polygonwkt.Shell()[0].Y)
 		}
-		if polygonwkt.Coordinates[0].Y != 10.0 {
-			t.Error("Expected Y to be 10.0 got ", polygonwkt.Coordinates[0].Y)
+		if polygonwkt.Holes()[0][0].X != 20.0 {
+			t.Error("Expected hole X to be 20.0 got ", polygonwkt.Holes()[0][0].X)
 		}
 	}
 
@@ -291,3 +546,807 @@ func TestPolygonHoles(t *testing.T) {
 	os.Stdout.Write(b)
 
 }
+
+// polygonWKT writes rings back out as a 2D POLYGON, joining them with sep so
+// the same rings can be parsed with different spacing.
+func polygonWKT(rings [][]Coordinate, sep string) string {
+	parts := []string{}
+	for _, ring := range rings {
+		coords := []string{}
+		for _, c := range ring {
+			coords = append(coords, strconv.FormatFloat(c.X, 'g', -1, 64)+" "+strconv.FormatFloat(c.Y, 'g', -1, 64))
+		}
+		parts = append(parts, "("+strings.Join(coords, ", ")+")")
+	}
+	return "POLYGON (" + strings.Join(parts, sep) + ")"
+}
+
+func TestPolygonRingsRoundTrip(t *testing.T) {
+
+	shell := []Coordinate{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 100}, {X: 0, Y: 100}, {X: 0, Y: 0}}
+	holeone := []Coordinate{{X: 10, Y: 10}, {X: 20, Y: 10}, {X: 20, Y: 20}, {X: 10, Y: 10}}
+	holetwo := []Coordinate{{X: 50, Y: 50}, {X: 60, Y: 50}, {X: 60, Y: 60}, {X: 50, Y: 50}}
+	holethree := []Coordinate{{X: 70.5, Y: 10}, {X: 80, Y: 10}, {X: 80, Y: 20.25}, {X: 70.5, Y: 10}}
+
+	polygons := [][][]Coordinate{
+		{shell},
+		{shell, holeone},
+		{shell, holeone, holetwo, holethree},
+	}
+	separators := []string{",", ", ", " , ", "\n,\t"}
+
+	for _, rings := range polygons {
+		for _, sep := range separators {
+
+			wkt := polygonWKT(rings, sep)
+			_, polygonwkt, err := ParseGeometry(wkt)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(polygonwkt.Rings, rings) {
+				t.Error("Expected rings ", rings, " got ", polygonwkt.Rings, " for ", wkt)
+			}
+			if len(polygonwkt.Holes()) != len(rings)-1 {
+				t.Error("Expected ", len(rings)-1, " holes got ", len(polygonwkt.Holes()), " for ", wkt)
+			}
+			if again := polygonWKT(polygonwkt.Rings, sep); again != wkt {
+				t.Error("Expected ", wkt, " got ", again)
+			}
+		}
+	}
+
+}
+
+func TestMultiPolygon(t *testing.T) {
+
+	var multipolygon string = "MULTIPOLYGON (((40 40, 20 45, 45 30, 40 40)), ((20 35, 10 30, 10 10, 30 5, 45 20, 20 35), (30 20, 20 15, 20 25, 30 20), (12 12, 14 12, 14 14, 12 12)))"
+	multipolygontype, multipolygonwkt, err := ParseGeometry(multipolygon)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if multipolygontype != "MULTIPOLYGON" {
+		t.Error("Expected MULTIPOLYGON got ", multipolygontype)
+	}
+
+	if len(multipolygonwkt.Parts) != 2 {
+		t.Fatal("MultiPolygon does not have 2 polygons, has ", strconv.Itoa(len(multipolygonwkt.Parts)))
+	}
+
+	first := multipolygonwkt.Parts[0]
+	if len(first.Shell()) != 4 || len(first.Holes()) != 0 {
+		t.Error("Expected first polygon to have 4 shell coordinates and 0 holes got ", strconv.Itoa(len(first.Shell())), " and ", strconv.Itoa(len(first.Holes())))
+	}
+
+	second := multipolygonwkt.Parts[1]
+	if len(second.Shell()) != 6 {
+		t.Error("Expected second polygon to have 6 shell coordinates got ", strconv.Itoa(len(second.Shell())))
+	}
+	if len(second.Holes()) != 2 {
+		t.Error("Expected second polygon to have 2 holes got ", strconv.Itoa(len(second.Holes())))
+	} else if second.Holes()[1][0].X != 12.0 {
+		t.Error("Expected X to be 12.0 got ", second.Holes()[1][0].X)
+	}
+
+}
+
+func TestMultiPolygonZ(t *testing.T) {
+
+	var multipolygon string = "MULTIPOLYGON Z (((0 0 1, 0 1 1, 1 1 1, 0 0 1)), EMPTY)"
+	multipolygontype, multipolygonwkt, err := ParseGeometry(multipolygon)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if multipolygontype != "MULTIPOLYGON Z" {
+		t.Error("Expected MULTIPOLYGON Z got ", multipolygontype)
+	}
+
+	if len(multipolygonwkt.Parts) != 2 {
+		t.Fatal("MultiPolygon does not have 2 polygons, has ", strconv.Itoa(len(multipolygonwkt.Parts)))
+	}
+	if multipolygonwkt.Parts[0].Shell()[2].Z != 1.0 {
+		t.Error("Expected Z to be 1.0 got ", multipolygonwkt.Parts[0].Shell()[2].Z)
+	}
+	if len(multipolygonwkt.Parts[1].Rings) != 0 {
+		t.Error("Expected second polygon to be empty got ", strconv.Itoa(len(multipolygonwkt.Parts[1].Rings)), " rings")
+	}
+
+}
+
+func TestMultiPolygonM(t *testing.T) {
+
+	var multipolygon string = "MULTIPOLYGON M (((0 0 5, 0 1 6, 1 1 7, 0 0 5)))"
+	multipolygontype, multipolygonwkt, err := ParseGeometry(multipolygon)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if multipolygontype != "MULTIPOLYGON M" {
+		t.Error("Expected MULTIPOLYGON M got ", multipolygontype)
+	}
+
+	if len(multipolygonwkt.Parts) != 1 {
+		t.Fatal("MultiPolygon does not have 1 polygon, has ", strconv.Itoa(len(multipolygonwkt.Parts)))
+	}
+	if multipolygonwkt.Parts[0].Shell()[1].M != 6.0 {
+		t.Error("Expected M to be 6.0 got ", multipolygonwkt.Parts[0].Shell()[1].M)
+	}
+
+}
+
+func TestMultiPolygonZM(t *testing.T) {
+
+	var multipolygon string = "MULTIPOLYGON ZM (((0 0 1 5, 0 1 1 6, 1 1 1 7, 0 0 1 5)), ((5 5 2 1, 5 6 2 1, 6 6 2 1, 5 5 2 1)))"
+	multipolygontype, multipolygonwkt, err := Multipolygon(multipolygon, "MULTIPOLYGON")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if multipolygontype != "MULTIPOLYGON ZM" {
+		t.Error("Expected MULTIPOLYGON ZM got ", multipolygontype)
+	}
+
+	if len(multipolygonwkt.Parts) != 2 {
+		t.Fatal("MultiPolygon does not have 2 polygons, has ", strconv.Itoa(len(multipolygonwkt.Parts)))
+	}
+	if multipolygonwkt.Parts[1].Shell()[0].Z != 2.0 {
+		t.Error("Expected Z to be 2.0 got ", multipolygonwkt.Parts[1].Shell()[0].Z)
+	}
+	if multipolygonwkt.Parts[0].Shell()[2].M != 7.0 {
+		t.Error("Expected M to be 7.0 got ", multipolygonwkt.Parts[0].Shell()[2].M)
+	}
+
+}
+
+func TestWhitespaceAndCase(t *testing.T) {
+
+	var line string = "linestring z\n(\t30 10 5 ,\r\n 10   30 5,40 40 5 )\n"
+	linetype, linewkt, err := ParseGeometry(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if linetype != "LINESTRING Z" {
+		t.Error("Expected LINESTRING Z got ", linetype)
+	}
+
+	if len(linewkt.Coordinates) != 3 {
+		t.Error("Expected 3 coordinates got ", strconv.Itoa(len(linewkt.Coordinates)))
+	} else {
+		if linewkt.Coordinates[1].X != 10.0 {
+			t.Error("Expected X to be 10.0 got ", linewkt.Coordinates[1].X)
+		}
+		if linewkt.Coordinates[2].Z != 5.0 {
+			t.Error("Expected Z to be 5.0 got ", linewkt.Coordinates[2].Z)
+		}
+	}
+
+}
+
+func TestScientificNotation(t *testing.T) {
+
+	var point string = "POINT (1.5e3 -2E-2)"
+	pointtype, pointwkt, err := ParseGeometry(point)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pointtype != "POINT" {
+		t.Error("Expected POINT got ", pointtype)
+	}
+
+	if len(pointwkt.Coordinates) != 1 {
+		t.Error("Point does not have 1 pair of coordinates, has ", strconv.Itoa(len(pointwkt.Coordinates)))
+	} else {
+		if pointwkt.Coordinates[0].X != 1500 {
+			t.Error("Expected X to be 1500 got ", pointwkt.Coordinates[0].X)
+		}
+		if pointwkt.Coordinates[0].Y != -0.02 {
+			t.Error("Expected Y to be -0.02 got ", pointwkt.Coordinates[0].Y)
+		}
+	}
+
+}
+
+func TestInferredDimension(t *testing.T) {
+
+	pointtype, pointwkt, err := ParseGeometry("POINT (1 2 3)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pointtype != "POINT Z" {
+		t.Error("Expected POINT Z got ", pointtype)
+	}
+	if len(pointwkt.Coordinates) != 1 || pointwkt.Coordinates[0].Z != 3 {
+		t.Error("Expected Z to be 3 got ", pointwkt.Coordinates)
+	}
+
+}
+
+func TestPolygonRingSpacing(t *testing.T) {
+
+	var polygon string = "POLYGON ((35 10, 45 45, 15 40, 10 20, 35 10) , ( 20 30, 35 35, 30 20, 20 30 ))"
+	polygontype, polygonwkt, err := ParseGeometry(polygon)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if polygontype != "POLYGON" {
+		t.Error("Expected POLYGON got ", polygontype)
+	}
+	if len(polygonwkt.Shell()) != 5 {
+		t.Error("Expected 5 shell coordinates got ", strconv.Itoa(len(polygonwkt.Shell())))
+	}
+	if len(polygonwkt.Holes()) != 1 || len(polygonwkt.Holes()[0]) != 4 {
+		t.Error("Expected 1 hole with 4 coordinates got ", polygonwkt.Holes())
+	}
+
+}
+
+func TestPolygonRingRules(t *testing.T) {
+
+	tests := []struct {
+		wkt      string
+		expected string
+		found    string
+	}{
+		{"POLYGON ((0 0, 1 0))", "at least 4 points in ring", "2"},
+		{"POLYGON ((0 0, 1 0, 0 0))", "at least 4 points in ring", "3"},
+		{"POLYGON ((0 0, 1 0, 1 1, 0 1))", "closed ring", "ring that ends at a different point"},
+		{"POLYGON Z ((0 0 0, 1 0 0, 1 1 0, 0 0 1))", "closed ring", "ring that ends at a different point"},
+		{"POLYGON ((0 0, 4 0, 4 4, 0 0), (1 1, 2 1, 1 1))", "at least 4 points in ring", "3"},
+		{"MULTIPOLYGON (((0 0, 1 0, 1 1, 0 0)), ((5 5, 6 5, 6 6)))", "at least 4 points in ring", "3"},
+	}
+
+	for _, test := range tests {
+		_, _, err := ParseGeometry(test.wkt)
+		perr, ok := err.(*ParseError)
+		if !ok {
+			t.Error("Expected a *ParseError for ", test.wkt, " got ", err)
+			continue
+		}
+		if perr.Expected != test.expected || perr.Found != test.found {
+			t.Error(test.wkt, ": expected ", test.expected, " and ", test.found, " got ", perr.Expected, " and ", perr.Found)
+		}
+	}
+
+}
+
+func TestMalformed(t *testing.T) {
+
+	malformed := []string{
+		"POINT (1)",
+		"POINT Z (1 2)",
+		"POINT (1 2",
+		"LINESTRING (1 2, 3 4) junk",
+		"LINESTRING (1 2, 3)",
+		"POLYGON (1 2, 3 4)",
+		"POINT (1 2 3 4 5)",
+		"POINT (1.2.3 4)",
+		"",
+	}
+
+	for _, wkt := range malformed {
+		wkttype, wktset, err := ParseGeometry(wkt)
+		if _, ok := err.(*ParseError); !ok {
+			t.Error("Expected a *ParseError for ", wkt, " got ", err)
+		}
+		if wkttype != "" || len(wktset.Coordinates) != 0 {
+			t.Error("Expected no result for ", wkt, " got ", wkttype, wktset)
+		}
+	}
+
+}
+
+func TestParseErrorPosition(t *testing.T) {
+
+	_, _, err := ParseGeometry("LINESTRING Z (30 10 5,\n  10 30)")
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatal("Expected a *ParseError got ", err)
+	}
+
+	if perr.Offset != 25 {
+		t.Error("Expected Offset to be 25 got ", perr.Offset)
+	}
+	if perr.Line != 2 {
+		t.Error("Expected Line to be 2 got ", perr.Line)
+	}
+	if perr.Column != 3 {
+		t.Error("Expected Column to be 3 got ", perr.Column)
+	}
+	if perr.Expected != "3 ordinates" {
+		t.Error("Expected Expected to be 3 ordinates got ", perr.Expected)
+	}
+	if perr.Found != "2" {
+		t.Error("Expected Found to be 2 got ", perr.Found)
+	}
+
+}
+
+func TestWrongParentType(t *testing.T) {
+
+	_, _, err := Line("POINT (1 2)", "LINESTRING")
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatal("Expected a *ParseError got ", err)
+	}
+	if perr.Expected != "LINESTRING" || perr.Found != `"POINT"` {
+		t.Error("Expected LINESTRING and \"POINT\" got ", perr.Expected, perr.Found)
+	}
+
+}
+
+func TestParsePointAndPolygon(t *testing.T) {
+
+	pointtype, pointwkt, err := ParsePoint("POINT Z (1 2 3)", "POINT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pointtype != "POINT Z" || !reflect.DeepEqual(pointwkt.Coordinates, []Coordinate{{X: 1, Y: 2, Z: 3}}) {
+		t.Error("Expected POINT Z (1 2 3) got ", pointtype, pointwkt)
+	}
+
+	polygontype, polygonwkt, err := ParsePolygon("POLYGON ((0 0, 4 0, 4 4, 0 0), (1 1, 2 1, 2 2, 1 1))", "POLYGON")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if polygontype != "POLYGON" || len(polygonwkt.Rings) != 2 || len(polygonwkt.Shell()) != 4 {
+		t.Error("Expected a POLYGON with a shell and one hole got ", polygontype, polygonwkt)
+	}
+
+	_, _, err = ParsePoint("LINESTRING (1 2, 3 4)", "POINT")
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatal("Expected a *ParseError got ", err)
+	}
+	if perr.Expected != "POINT" || perr.Found != `"LINESTRING"` {
+		t.Error("Expected POINT and \"LINESTRING\" got ", perr.Expected, perr.Found)
+	}
+
+	if _, _, err := ParsePolygon("MULTIPOLYGON EMPTY", "POLYGON"); err == nil {
+		t.Error("Expected an error for a MULTIPOLYGON passed to ParsePolygon")
+	}
+
+}
+
+func TestGetCoordinateTooFewOrdinates(t *testing.T) {
+
+	if _, err := GetCoordinate([]float64{1, 2}, "POINT Z"); err == nil {
+		t.Error("Expected an error for a POINT Z with two ordinates")
+	}
+
+	coordinate, err := GetCoordinate([]float64{1, 2, 3}, "MULTIPOINT Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if coordinate.Z != 3 || coordinate.M != 0 {
+		t.Error("Expected Z to be 3 and M to be 0 got ", coordinate)
+	}
+
+}
+
+func TestGeometryCollection(t *testing.T) {
+
+	var collection string = "GEOMETRYCOLLECTION (POINT (4 6), LINESTRING (4 6, 7 10), POLYGON ((0 0, 1 0, 1 1, 0 0)))"
+	collectiontype, collectionwkt, err := ParseGeometry(collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if collectiontype != "GEOMETRYCOLLECTION" {
+		t.Error("Expected GEOMETRYCOLLECTION got ", collectiontype)
+	}
+
+	if len(collectionwkt.Children) != 3 {
+		t.Fatal("GeometryCollection does not have 3 members, has ", strconv.Itoa(len(collectionwkt.Children)))
+	}
+
+	types := []string{"POINT", "LINESTRING", "POLYGON"}
+	for i, child := range collectionwkt.Children {
+		if child.WTKType != types[i] {
+			t.Error("Expected ", types[i], " got ", child.WTKType)
+		}
+		if len(child.Geometries) != 1 {
+			t.Error("Expected member to have 1 coordinate set got ", strconv.Itoa(len(child.Geometries)))
+		}
+	}
+
+	if collectionwkt.Children[1].Geometries[0].Coordinates[1].X != 7.0 {
+		t.Error("Expected X to be 7.0 got ", collectionwkt.Children[1].Geometries[0].Coordinates[1].X)
+	}
+	if len(collectionwkt.Children[2].Geometries[0].Shell()) != 4 {
+		t.Error("Expected polygon shell to have 4 coordinates got ", strconv.Itoa(len(collectionwkt.Children[2].Geometries[0].Shell())))
+	}
+
+}
+
+func TestGeometryCollectionNested(t *testing.T) {
+
+	var collection string = "GEOMETRYCOLLECTION Z (POINT Z (1 2 3), GEOMETRYCOLLECTION (LINESTRING M (1 2 5, 3 4 6), GEOMETRYCOLLECTION EMPTY), MULTIPOINT (1 2, 3 4))"
+	collectiontype, collectionwkt, err := Geometrycollection(collection, "GEOMETRYCOLLECTION")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if collectiontype != "GEOMETRYCOLLECTION Z" {
+		t.Error("Expected GEOMETRYCOLLECTION Z got ", collectiontype)
+	}
+
+	if len(collectionwkt.Children) != 3 {
+		t.Fatal("GeometryCollection does not have 3 members, has ", strconv.Itoa(len(collectionwkt.Children)))
+	}
+
+	if collectionwkt.Children[0].WTKType != "POINT Z" {
+		t.Error("Expected POINT Z got ", collectionwkt.Children[0].WTKType)
+	}
+	if collectionwkt.Children[2].WTKType != "MULTIPOINT" {
+		t.Error("Expected MULTIPOINT got ", collectionwkt.Children[2].WTKType)
+	}
+
+	nested := collectionwkt.Children[1]
+	if nested.WTKType != "GEOMETRYCOLLECTION" {
+		t.Fatal("Expected GEOMETRYCOLLECTION got ", nested.WTKType)
+	}
+	if len(nested.Geometries[0].Children) != 2 {
+		t.Fatal("Nested GeometryCollection does not have 2 members, has ", strconv.Itoa(len(nested.Geometries[0].Children)))
+	}
+
+	line := nested.Geometries[0].Children[0]
+	if line.WTKType != "LINESTRING M" {
+		t.Error("Expected LINESTRING M got ", line.WTKType)
+	}
+	if line.Geometries[0].Coordinates[1].M != 6.0 {
+		t.Error("Expected M to be 6.0 got ", line.Geometries[0].Coordinates[1].M)
+	}
+
+	empty := nested.Geometries[0].Children[1]
+	if empty.WTKType != "GEOMETRYCOLLECTION" || len(empty.Geometries[0].Children) != 0 {
+		t.Error("Expected an empty GEOMETRYCOLLECTION got ", empty)
+	}
+
+}
+
+func TestGeometryCollectionDepth(t *testing.T) {
+
+	nest := func(depth int) string {
+		return strings.Repeat("GEOMETRYCOLLECTION (", depth) + "POINT (1 2)" + strings.Repeat(")", depth)
+	}
+
+	if _, _, err := ParseGeometry(nest(maxWKTDepth)); err != nil {
+		t.Error("Expected collections nested ", maxWKTDepth, " deep to parse got ", err)
+	}
+
+	// Far deeper input must fail as soon as the limit is passed, rather
+	// than run out of stack.
+	for _, depth := range []int{maxWKTDepth + 1, 1000000} {
+		_, _, err := ParseGeometry(nest(depth))
+		perr, ok := err.(*ParseError)
+		if !ok {
+			t.Fatal("Expected a *ParseError for collections nested ", depth, " deep got ", err)
+		}
+		offset := len("GEOMETRYCOLLECTION (")*maxWKTDepth + len("GEOMETRYCOLLECTION ")
+		if perr.Offset != offset || perr.Expected != "collections nested at most 128 deep" || perr.Found != "129" {
+			t.Error("Expected an error at offset ", offset, " got ", perr)
+		}
+	}
+
+}
+
+func TestTriangle(t *testing.T) {
+
+	var triangle string = "TRIANGLE ((0 0 0, 0 1 0, 1 1 0, 0 0 0))"
+	triangletype, trianglewkt, err := ParseGeometry(triangle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if triangletype != "TRIANGLE Z" {
+		t.Error("Expected TRIANGLE Z got ", triangletype)
+	}
+	if len(trianglewkt.Rings) != 1 || len(trianglewkt.Shell()) != 4 {
+		t.Error("Expected 1 ring of 4 coordinates got ", trianglewkt.Rings)
+	}
+
+	for _, wkt := range []string{"TRIANGLE ((0 0, 0 1, 1 1, 1 0, 0 0))", "TRIANGLE ((0 0, 0 1, 1 1, 0 0), (0 0, 0 1, 1 1, 0 0))"} {
+		if _, _, err := ParseGeometry(wkt); err == nil {
+			t.Error("Expected an error for ", wkt)
+		}
+	}
+
+}
+
+func TestTIN(t *testing.T) {
+
+	var tin string = "TIN Z (((0 0 0, 0 0 1, 0 1 0, 0 0 0)), ((0 0 0, 0 1 0, 1 1 0, 0 0 0)))"
+	tintype, tinwkt, err := ParseGeometry(tin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tintype != "TIN Z" {
+		t.Error("Expected TIN Z got ", tintype)
+	}
+
+	if len(tinwkt.Parts) != 2 {
+		t.Fatal("TIN does not have 2 faces, has ", strconv.Itoa(len(tinwkt.Parts)))
+	}
+	if len(tinwkt.Parts[1].Rings) != 1 || tinwkt.Parts[1].Shell()[2].X != 1.0 {
+		t.Error("Expected second face to be a triangle with X of 1.0 got ", tinwkt.Parts[1].Rings)
+	}
+
+}
+
+func TestPolyhedralSurface(t *testing.T) {
+
+	var surface string = "POLYHEDRALSURFACE Z (((0 0 0, 0 1 0, 1 1 0, 1 0 0, 0 0 0)), ((0 0 0, 0 1 0, 0 1 1, 0 0 1, 0 0 0), (0 0.2 0.2, 0 0.4 0.2, 0 0.4 0.4, 0 0.2 0.2)), EMPTY)"
+	surfacetype, surfacewkt, err := ParseGeometry(surface)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if surfacetype != "POLYHEDRALSURFACE Z" {
+		t.Error("Expected POLYHEDRALSURFACE Z got ", surfacetype)
+	}
+
+	if len(surfacewkt.Parts) != 3 {
+		t.Fatal("PolyhedralSurface does not have 3 faces, has ", strconv.Itoa(len(surfacewkt.Parts)))
+	}
+	if len(surfacewkt.Parts[0].Shell()) != 5 {
+		t.Error("Expected first face to have 5 coordinates got ", strconv.Itoa(len(surfacewkt.Parts[0].Shell())))
+	}
+	if len(surfacewkt.Parts[1].Holes()) != 1 || surfacewkt.Parts[1].Holes()[0][1].Y != 0.4 {
+		t.Error("Expected second face to have 1 hole with Y of 0.4 got ", surfacewkt.Parts[1].Holes())
+	}
+	if len(surfacewkt.Parts[2].Rings) != 0 {
+		t.Error("Expected third face to be empty got ", surfacewkt.Parts[2].Rings)
+	}
+
+}
+
+func TestEWKT(t *testing.T) {
+
+	var ewkt string = "SRID=4326;POINT(1 2)"
+	ewkttype, ewktwkt, err := ParseGeometry(ewkt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ewkttype != "POINT" {
+		t.Error("Expected POINT got ", ewkttype)
+	}
+	if ewktwkt.Coordinates[0].Y != 2.0 {
+		t.Error("Expected Y to be 2.0 got ", ewktwkt.Coordinates[0].Y)
+	}
+
+	g, err := Parse("srid = 3857 ; MULTIPOINT Z (1 2 3)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.SRID() != 3857 {
+		t.Error("Expected SRID 3857 got ", g.SRID())
+	}
+
+	g, err = Parse("POINT (1 2)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.SRID() != 0 {
+		t.Error("Expected SRID 0 got ", g.SRID())
+	}
+
+	for _, wkt := range []string{"SRID=4326 POINT (1 2)", "SRID=;POINT (1 2)", "SRID=4.5;POINT (1 2)", "SRID=4326;", "POINT (1 2);SRID=4326"} {
+		if _, err := Parse(wkt); err == nil {
+			t.Error("Expected an error for ", wkt)
+		}
+	}
+
+	_, err = Parse("SRID=4.5;POINT (1 2)")
+	perr, ok := err.(*ParseError)
+	if !ok || perr.Expected != "integer SRID" || perr.Offset != 5 {
+		t.Error("Expected integer SRID at offset 5 got ", perr)
+	}
+
+}
+
+func TestLegacyM(t *testing.T) {
+
+	var legacy string = "POINTM(1 2 3)"
+	legacytype, legacywkt, err := ParseGeometry(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if legacytype != "POINT M" {
+		t.Error("Expected POINT M got ", legacytype)
+	}
+	if legacywkt.Coordinates[0].M != 3.0 {
+		t.Error("Expected M to be 3.0 got ", legacywkt.Coordinates[0].M)
+	}
+
+	for _, wkt := range []string{
+		"SRID=4326;GEOMETRYCOLLECTIONM(POINTM(1 2 3),LINESTRINGM(1 2 3,4 5 6))",
+		"COMPOUNDCURVEM(CIRCULARSTRINGM(0 0 1,1 1 2,2 0 3),(2 0 3,4 0 4))",
+		"MULTIPOINTM(1 2 3)",
+	} {
+		g, err := Parse(wkt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.Layout() != XYM {
+			t.Error("Expected XYM for ", wkt, " got ", g.Layout())
+		}
+	}
+
+	if _, _, err := Line("LINESTRINGM(1 2 3, 4 5 6)", "LINESTRING"); err != nil {
+		t.Error("Expected LINESTRINGM to be accepted as a LINESTRING got ", err)
+	}
+
+	for _, wkt := range []string{"POINTM(1 2)", "POINTM Z (1 2 3)", "POINTZ(1 2 3)", "COMPOUNDCURVE Z (CIRCULARSTRINGM(0 0 1, 1 1 2, 2 0 3))"} {
+		if _, err := Parse(wkt); err == nil {
+			t.Error("Expected an error for ", wkt)
+		}
+	}
+
+}
+
+func TestParser(t *testing.T) {
+
+	buf := make([]Coordinate, 0, 64)
+	parser := NewParser(buf)
+
+	for _, wkt := range []string{
+		"LINESTRING (30 10, 10 30, 40 40)",
+		"SRID=4326;MULTIPOLYGON Z (((40 40 1, 20 45 1, 45 30 1, 40 40 1)), ((20 35 2, 10 30 2, 10 10 2, 30 5 2, 20 35 2), (30 20 2, 20 15 2, 20 25 2, 30 20 2)))",
+		"GEOMETRYCOLLECTION (POINT (4 6), LINESTRING EMPTY, POLYGON ((0 0, 1 0, 1 1, 0 0)))",
+		"CURVEPOLYGON (COMPOUNDCURVE (CIRCULARSTRING (0 0, 2 0, 2 1, 2 3, 4 3), (4 3, 4 5, 1 4, 0 0)))",
+	} {
+		g, err := parser.Parse([]byte(wkt))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := Parse(wkt)
+		if !reflect.DeepEqual(g, want) {
+			t.Error("Expected ", want, " got ", g)
+		}
+	}
+
+	// The coordinates of the next geometry go in the caller's buffer.
+	g, err := parser.Parse([]byte("LINESTRING (1 2, 3 4)"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := g.(*LineString)
+	if &line.Coordinates[0] != &buf[:1][0] {
+		t.Error("Expected the coordinates to be stored in the buffer passed to NewParser")
+	}
+
+	// Appending to one list must not overwrite the next.
+	g, err = parser.Parse([]byte("MULTILINESTRING ((1 2, 3 4), (5 6, 7 8))"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	multiline := g.(*MultiLineString)
+	multiline.LineStrings[0].Coordinates = append(multiline.LineStrings[0].Coordinates, Coordinate{X: 9, Y: 9})
+	if multiline.LineStrings[1].Coordinates[0].X != 5 {
+		t.Error("Expected the second line to be unchanged got ", multiline.LineStrings[1])
+	}
+
+	if _, err := parser.Parse([]byte("POINT (1")); err == nil {
+		t.Error("Expected an error for an unclosed POINT")
+	}
+
+}
+
+func TestParseBytes(t *testing.T) {
+
+	wkt := "polygon z ((0 0 1, 1 0 1, 1 1 1, 0 0 1))"
+	g, err := ParseBytes([]byte(wkt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := Parse(wkt)
+	if !reflect.DeepEqual(g, want) {
+		t.Error("Expected ", want, " got ", g)
+	}
+
+	_, err = ParseBytes([]byte("POINT (1 2)\nPOINT"))
+	if perr, ok := err.(*ParseError); !ok || perr.Line != 2 || perr.Column != 1 {
+		t.Error("Expected an error at line 2, column 1 got ", err)
+	}
+
+}
+
+// benchmarks are a small and a large geometry, the latter a polygon with
+// 10,000 vertices like the parcels and coastlines of a bulk import.
+var benchmarks = []struct {
+	name string
+	wkt  string
+}{
+	{"Point", "POINT (-122.4194155 37.7749295)"},
+	{"Polygon", benchmarkPolygon(10000)},
+}
+
+func benchmarkPolygon(n int) string {
+	var b strings.Builder
+	b.WriteString("POLYGON ((")
+	for i := 0; i < n; i++ {
+		b.WriteString(strconv.FormatFloat(-122.4194155+float64(i)*0.0000137, 'f', 7, 64))
+		b.WriteByte(' ')
+		b.WriteString(strconv.FormatFloat(37.7749295-float64(i%97)*0.0000211, 'f', 7, 64))
+		b.WriteString(", ")
+	}
+	b.WriteString("-122.4194155 37.7749295))")
+	return b.String()
+}
+
+// BenchmarkParseGeometry measures the original API, which also builds the
+// CoordinateSet.
+func BenchmarkParseGeometry(b *testing.B) {
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.SetBytes(int64(len(bm.wkt)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, _, err := ParseGeometry(bm.wkt); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.SetBytes(int64(len(bm.wkt)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := Parse(bm.wkt); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkParseBytes(b *testing.B) {
+	for _, bm := range benchmarks {
+		data := []byte(bm.wkt)
+		b.Run(bm.name, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := ParseBytes(data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkParser measures the bulk load path, where one Parser is reused
+// for every input.
+func BenchmarkParser(b *testing.B) {
+	for _, bm := range benchmarks {
+		data := []byte(bm.wkt)
+		b.Run(bm.name, func(b *testing.B) {
+			parser := NewParser(nil)
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := parser.Parse(data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkRemoveAllAlphabet(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		RemoveAllAlphabet("MULTIPOINT ((10 40), (40 30), (20 20), (30 10))")
+	}
+}
@@ -0,0 +1,257 @@
+package wktparse
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MarshalOptions control how WKT is written. The zero value writes each
+// ordinate with the fewest digits that parse back to the same float64, and
+// the whole geometry on one line.
+type MarshalOptions struct {
+	Fixed     bool // Round ordinates to Precision decimal places, dropping trailing zeros
+	Precision int
+	Pretty    bool // Put each member of a multi-part geometry on its own, indented line
+}
+
+// Marshal writes g as WKT with the default options, so that Parse returns a
+// geometry equal to g. If g has an SRID the result is EWKT, with the SRID=n;
+// prefix PostGIS writes. It returns an error if g is nil or has an ordinate
+// that WKT cannot hold, such as NaN or an infinity.
+func Marshal(g Geometry) (string, error) {
+	return MarshalOptions{}.Marshal(g)
+}
+
+// Marshal writes g as WKT using the options.
+func (o MarshalOptions) Marshal(g Geometry) (string, error) {
+
+	if g == nil {
+		return "", errors.New("wktparse: cannot marshal a nil geometry")
+	}
+
+	w := &writer{options: o}
+	w.srid(g)
+	w.geometry(g)
+
+	return w.String(), w.err
+}
+
+// wkt is used by the String methods, which have nowhere to report an
+// error, so non-finite ordinates are written as NaN, +Inf or -Inf.
+func wkt(g Geometry) string {
+	w := &writer{}
+	w.srid(g)
+	w.geometry(g)
+	return w.String()
+}
+
+func (p *Point) String() string              { return wkt(p) }
+func (l *LineString) String() string         { return wkt(l) }
+func (p *Polygon) String() string            { return wkt(p) }
+func (t *Triangle) String() string           { return wkt(t) }
+func (m *MultiPoint) String() string         { return wkt(m) }
+func (m *MultiLineString) String() string    { return wkt(m) }
+func (m *MultiPolygon) String() string       { return wkt(m) }
+func (s *PolyhedralSurface) String() string  { return wkt(s) }
+func (t *TIN) String() string                { return wkt(t) }
+func (c *GeometryCollection) String() string { return wkt(c) }
+func (c *CircularString) String() string     { return wkt(c) }
+func (c *CompoundCurve) String() string      { return wkt(c) }
+func (c *CurvePolygon) String() string       { return wkt(c) }
+func (m *MultiCurve) String() string         { return wkt(m) }
+func (m *MultiSurface) String() string       { return wkt(m) }
+
+type writer struct {
+	strings.Builder
+	options MarshalOptions
+	depth   int
+	err     error
+}
+
+// srid writes the EWKT prefix if g has an SRID.
+func (w *writer) srid(g Geometry) {
+	if srid := g.SRID(); srid != 0 {
+		w.WriteString("SRID=")
+		w.WriteString(strconv.Itoa(srid))
+		w.WriteByte(';')
+	}
+}
+
+// geometry writes <geometry tagged text>. Members of a GEOMETRYCOLLECTION
+// are written the same way, each with its own dimension tag.
+func (w *writer) geometry(g Geometry) {
+	w.WriteString(typeName(g.Type(), g.Layout().Tag()))
+	w.WriteByte(' ')
+	w.text(g)
+}
+
+// member writes a member of one of the curve types. These share the layout
+// of the geometry that contains them, so they have no dimension tag, and
+// LINESTRING and POLYGON members have no keyword either.
+func (w *writer) member(g Geometry) {
+	switch g.(type) {
+	case *LineString, *Polygon:
+	default:
+		w.WriteString(g.Type())
+		w.WriteByte(' ')
+	}
+	w.text(g)
+}
+
+func (w *writer) text(g Geometry) {
+
+	switch g := g.(type) {
+	case *Point:
+		if g.IsEmpty() {
+			w.WriteString("EMPTY")
+			return
+		}
+		w.WriteByte('(')
+		w.coordinate(g.Layout(), g.Coordinate)
+		w.WriteByte(')')
+
+	case *LineString:
+		w.coordinates(g.Layout(), g.Coordinates)
+
+	case *CircularString:
+		w.coordinates(g.Layout(), g.Coordinates)
+
+	case *Polygon:
+		w.rings(g.Layout(), g.Rings)
+
+	case *Triangle:
+		w.rings(g.Layout(), g.Rings)
+
+	case *MultiPoint:
+		w.list(len(g.Points), false, func(i int) { w.text(g.Points[i]) })
+
+	case *MultiLineString:
+		w.list(len(g.LineStrings), true, func(i int) { w.text(g.LineStrings[i]) })
+
+	case *MultiPolygon:
+		w.list(len(g.Polygons), true, func(i int) { w.text(g.Polygons[i]) })
+
+	case *PolyhedralSurface:
+		w.list(len(g.Polygons), true, func(i int) { w.text(g.Polygons[i]) })
+
+	case *TIN:
+		w.list(len(g.Triangles), true, func(i int) { w.text(g.Triangles[i]) })
+
+	case *CompoundCurve:
+		w.list(len(g.Segments), true, func(i int) { w.member(g.Segments[i]) })
+
+	case *CurvePolygon:
+		w.list(len(g.Rings), true, func(i int) { w.member(g.Rings[i]) })
+
+	case *MultiCurve:
+		w.list(len(g.Curves), true, func(i int) { w.member(g.Curves[i]) })
+
+	case *MultiSurface:
+		w.list(len(g.Surfaces), true, func(i int) { w.member(g.Surfaces[i]) })
+
+	case *GeometryCollection:
+		w.list(len(g.Geometries), true, func(i int) { w.geometry(g.Geometries[i]) })
+
+	default:
+		w.WriteString("EMPTY")
+		if w.err == nil {
+			w.err = fmt.Errorf("wktparse: cannot marshal %T", g)
+		}
+	}
+}
+
+// list writes EMPTY or a bracketed, comma separated list of n items. In
+// pretty mode a nested list, one whose items are lists themselves, has
+// each item on its own line.
+func (w *writer) list(n int, nested bool, item func(i int)) {
+
+	if n == 0 {
+		w.WriteString("EMPTY")
+		return
+	}
+
+	pretty := w.options.Pretty && nested
+
+	w.WriteByte('(')
+	w.depth++
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		if pretty {
+			w.newline()
+		} else if i > 0 {
+			w.WriteByte(' ')
+		}
+		item(i)
+	}
+	w.depth--
+	if pretty {
+		w.newline()
+	}
+	w.WriteByte(')')
+}
+
+func (w *writer) newline() {
+	w.WriteByte('\n')
+	for i := 0; i < w.depth; i++ {
+		w.WriteString("  ")
+	}
+}
+
+func (w *writer) rings(layout Layout, rings [][]Coordinate) {
+	w.list(len(rings), true, func(i int) { w.coordinates(layout, rings[i]) })
+}
+
+func (w *writer) coordinates(layout Layout, coordinates []Coordinate) {
+	w.list(len(coordinates), false, func(i int) { w.coordinate(layout, coordinates[i]) })
+}
+
+// coordinate writes the ordinates the layout has, separated by spaces.
+func (w *writer) coordinate(layout Layout, c Coordinate) {
+	for i, v := range layout.Ordinates(c) {
+		if i > 0 {
+			w.WriteByte(' ')
+		}
+		w.WriteString(w.number(v))
+	}
+}
+
+func (w *writer) number(v float64) string {
+
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		if w.err == nil {
+			w.err = fmt.Errorf("wktparse: cannot write %v in WKT", v)
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+
+	return formatNumber(v, w.options.Fixed, w.options.Precision)
+}
+
+// formatNumber writes a finite float64 with the fewest digits that parse
+// back to the same value or, if fixed is set, rounded to precision decimal
+// places without trailing zeros.
+func formatNumber(v float64, fixed bool, precision int) string {
+
+	if fixed {
+		s := strconv.FormatFloat(v, 'f', precision, 64)
+		if strings.Contains(s, ".") {
+			s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+		}
+		if s == "-0" {
+			s = "0"
+		}
+		return s
+	}
+
+	// Plain decimals unless that would mean a long run of zeros, as in
+	// 1e+21 or 1e-07.
+	if abs := math.Abs(v); v != 0 && (abs < 1e-6 || abs >= 1e21) {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,254 @@
+package wktparse
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// roundTripFixtures are the valid WKT strings used by the other tests.
+var roundTripFixtures = []string{
+	"GEOMETRYCOLLECTION(POINT(4 6),LINESTRING(4 6,7 10))",
+	"TRIANGLE((0 0 0,0 1 0,1 1 0,0 0 0))",
+	"POINT EMPTY",
+	"POINT(123.45 543.21)",
+	"POINT Z(123.45 543.21 65.6)",
+	"POINT ZM(123.45 543.21 65.6 100.0)",
+	"MULTIPOINT (10 40, 40 30, 20 20, 30 10)",
+	"MULTIPOINT ((10 40), (40 30), (20 20), (30 10))",
+	"MULTIPOINT Z ((10 40 1), (40 30 2))",
+	"MULTIPOINT M (10 40 7, 40 30 8)",
+	"MULTIPOINT ZM ((10 40 1 7), EMPTY, (40 30 2 8))",
+	"MULTIPOINT EMPTY",
+	"LINESTRING (30 10, 10 30, 40 40)",
+	"LINESTRING Z (30 10 5, 10 30 5, 40 40 5)",
+	"LINESTRING M (30 10 10, 10 30 9, 40 40 8)",
+	"LINESTRING ZM (30 10 5 10, 10 30 5 9, 40 40 5 8)",
+	"MULTILINESTRING ((10 10, 20 20, 10 40), (40 40, 30 30, 40 20, 30 10))",
+	"MULTILINESTRING Z ((10 10 1, 20 20 2), (40 40 3, 30 30 4))",
+	"MULTILINESTRING M ((10 10 1, 20 20 2), EMPTY)",
+	"MULTILINESTRING ZM ((10 10 1 5, 20 20 2 6))",
+	"POLYGON ((35 10, 45 45, 15 40, 10 20, 35 10),(20 30, 35 35, 30 20, 20 30))",
+	"MULTIPOLYGON (((40 40, 20 45, 45 30, 40 40)), ((20 35, 10 30, 10 10, 30 5, 45 20, 20 35), (30 20, 20 15, 20 25, 30 20), (12 12, 14 12, 14 14, 12 12)))",
+	"MULTIPOLYGON Z (((0 0 1, 0 1 1, 1 1 1, 0 0 1)), EMPTY)",
+	"MULTIPOLYGON M (((0 0 5, 0 1 6, 1 1 7, 0 0 5)))",
+	"MULTIPOLYGON ZM (((0 0 1 5, 0 1 1 6, 1 1 1 7, 0 0 1 5)), ((5 5 2 1, 5 6 2 1, 6 6 2 1, 5 5 2 1)))",
+	"POINT (1.5e3 -2E-2)",
+	"POINT (1 2 3)",
+	"POLYGON ((35 10, 45 45, 15 40, 10 20, 35 10) , ( 20 30, 35 35, 30 20, 20 30 ))",
+	"POINT (1 2)",
+	"GEOMETRYCOLLECTION (POINT (4 6), LINESTRING (4 6, 7 10), POLYGON ((0 0, 1 0, 1 1, 0 0)))",
+	"GEOMETRYCOLLECTION Z (POINT Z (1 2 3), GEOMETRYCOLLECTION (LINESTRING M (1 2 5, 3 4 6), GEOMETRYCOLLECTION EMPTY), MULTIPOINT (1 2, 3 4))",
+	"TRIANGLE ((0 0 0, 0 1 0, 1 1 0, 0 0 0))",
+	"TIN Z (((0 0 0, 0 0 1, 0 1 0, 0 0 0)), ((0 0 0, 0 1 0, 1 1 0, 0 0 0)))",
+	"POLYHEDRALSURFACE Z (((0 0 0, 0 1 0, 1 1 0, 1 0 0, 0 0 0)), ((0 0 0, 0 1 0, 0 1 1, 0 0 1, 0 0 0), (0 0.2 0.2, 0 0.4 0.2, 0 0.4 0.4, 0 0.2 0.2)), EMPTY)",
+	"CIRCULARSTRING (0 0, 1 1, 2 0, 3 -1, 4 0)",
+	"COMPOUNDCURVE Z (CIRCULARSTRING (0 0 1, 1 1 1, 2 0 1), (2 0 1, 4 0 2))",
+	"CURVEPOLYGON (COMPOUNDCURVE (CIRCULARSTRING (0 0, 2 0, 2 1, 2 3, 4 3), (4 3, 4 5, 1 4, 0 0)), CIRCULARSTRING (1.7 1, 1.4 0.4, 1.6 0.4, 1.6 0.5, 1.7 1), (2 2, 2.5 2, 2 2.5, 2 2))",
+	"MULTICURVE ((0 0, 5 5), CIRCULARSTRING (4 0, 4 4, 8 4))",
+	"MULTISURFACE (CURVEPOLYGON (CIRCULARSTRING (0 0, 4 0, 4 4, 0 4, 0 0)), ((10 10, 14 12, 11 10, 10 10)))",
+	"CIRCULARSTRING Z (0 0 0, 1 1 5, 2 0 10)",
+	"CIRCULARSTRING (0 0, 10 10, 20 0)",
+	"CURVEPOLYGON (COMPOUNDCURVE (CIRCULARSTRING (0 0, 2 2, 4 0), (4 0, 0 0)), CIRCULARSTRING (1 0.5, 2 1.5, 1 0.5))",
+	"MULTISURFACE (CURVEPOLYGON (CIRCULARSTRING (0 0, 4 0, 0 0)), ((10 10, 14 12, 11 10, 10 10)))",
+	"MULTICURVE M ((0 0 1, 5 5 2), CIRCULARSTRING (4 0 1, 4 4 1, 8 4 1))",
+	"CIRCULARSTRING (0 0, 2 0, 2 2)",
+	"LINESTRING (1 2, 3 4)",
+	"POLYGON ((0 0, 1 0, 1 1, 0 0))",
+	"MULTIPOINT (1 2, 3 4)",
+	"MULTILINESTRING ((1 2, 3 4))",
+	"MULTIPOLYGON (((0 0, 1 0, 1 1, 0 0)))",
+	"TRIANGLE ((0 0, 1 0, 1 1, 0 0))",
+	"TIN (((0 0, 1 0, 1 1, 0 0)))",
+	"POLYHEDRALSURFACE (((0 0, 1 0, 1 1, 0 0)))",
+	"GEOMETRYCOLLECTION (POINT (1 2))",
+	"CIRCULARSTRING (0 0, 1 1, 2 0)",
+	"COMPOUNDCURVE ((0 0, 1 1))",
+	"CURVEPOLYGON ((0 0, 1 0, 1 1, 0 0))",
+	"MULTICURVE ((0 0, 1 1))",
+	"MULTISURFACE (((0 0, 1 0, 1 1, 0 0)))",
+	"POINT Z (1 2 3)",
+	"POINT M (1 2 3)",
+	"POINT ZM (1 2 3 4)",
+	"POINT (1 2 3 4)",
+	"MULTILINESTRING (EMPTY, (1 2 3, 4 5 6))",
+	"LINESTRING EMPTY",
+	"MULTIPOINT (EMPTY)",
+	"MULTIPOINT (EMPTY, (1 2))",
+	"GEOMETRYCOLLECTION (POINT EMPTY)",
+	"MULTIPOLYGON EMPTY",
+	"GEOMETRYCOLLECTION (POINT (-1 5), LINESTRING (2 -3, 4 0), POLYGON EMPTY)",
+	"POINT ZM (1 2 0 3)",
+	"POINT Z (1 2 0)",
+	"GEOMETRYCOLLECTION (POLYGON ((0 0, 1 0, 1 1, 0 0)), MULTILINESTRING ((1 2, 3 4)))",
+	"LINESTRING ZM (1 2 3 4, 5 6 7 8)",
+	"POINT M (1 2 4)",
+	"GEOMETRYCOLLECTION (POINT (1 2), POINT M (3 4 5))",
+	"MULTIPOLYGON ZM (((0 0 1 2, 1 0 1 2, 1 1 1 2, 0 0 1 2)))",
+	"CIRCULARSTRING M (0 0 1, 1 1 2, 2 0 3)",
+	"SRID=4326;POINT(1 2)",
+	"srid = 3857 ; MULTIPOINT Z (1 2 3)",
+	"POINTM(1 2 3)",
+	"SRID=4326;GEOMETRYCOLLECTIONM(POINTM(1 2 3),LINESTRINGM(1 2 3,4 5 6))",
+	"COMPOUNDCURVEM(CIRCULARSTRINGM(0 0 1,1 1 2,2 0 3),(2 0 3,4 0 4))",
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+
+	options := []MarshalOptions{{}, {Pretty: true}}
+
+	for _, wkt := range roundTripFixtures {
+
+		g, err := Parse(wkt)
+		if err != nil {
+			t.Fatal(wkt, ": ", err)
+		}
+
+		for _, o := range options {
+			out, err := o.Marshal(g)
+			if err != nil {
+				t.Error(wkt, ": ", err)
+				continue
+			}
+			parsed, err := Parse(out)
+			if err != nil {
+				t.Error("Could not parse ", out, ": ", err)
+				continue
+			}
+			if !reflect.DeepEqual(parsed, g) {
+				t.Error("Expected ", wkt, " to round trip, got ", out)
+			}
+		}
+	}
+
+}
+
+func TestMarshal(t *testing.T) {
+
+	tests := map[string]string{
+		"POINT(123.45 543.21)":               "POINT (123.45 543.21)",
+		"point zm (1 2 3 4)":                 "POINT ZM (1 2 3 4)",
+		"POINT (1 2 3)":                      "POINT Z (1 2 3)",
+		"POINT (1.5e3 -2E-2)":                "POINT (1500 -0.02)",
+		"POINT (1e21 1e-7)":                  "POINT (1e+21 1e-07)",
+		"MULTIPOINT (1 2, EMPTY)":            "MULTIPOINT ((1 2), EMPTY)",
+		"POLYGON ((0 0,1 0 , 1 1,0 0))":      "POLYGON ((0 0, 1 0, 1 1, 0 0))",
+		"GEOMETRYCOLLECTION (POINT (1 2 3))": "GEOMETRYCOLLECTION (POINT Z (1 2 3))",
+		"COMPOUNDCURVE Z (CIRCULARSTRING Z (0 0 1, 1 1 1, 2 0 1), (2 0 1, 4 0 2))": "COMPOUNDCURVE Z (CIRCULARSTRING (0 0 1, 1 1 1, 2 0 1), (2 0 1, 4 0 2))",
+		"MULTISURFACE (POLYGON ((0 0, 1 0, 1 1, 0 0)))":                            "MULTISURFACE (((0 0, 1 0, 1 1, 0 0)))",
+		"TIN EMPTY":               "TIN EMPTY",
+		"SRID=4326;POINTM(1 2 3)": "SRID=4326;POINT M (1 2 3)",
+		"SRID=-1;TIN EMPTY":       "SRID=-1;TIN EMPTY",
+	}
+
+	for in, want := range tests {
+		g, err := Parse(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Marshal(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Error("Expected ", want, " got ", got)
+		}
+		if s := g.(interface{ String() string }).String(); s != want {
+			t.Error("Expected String() to give ", want, " got ", s)
+		}
+	}
+
+}
+
+func TestMarshalShortestFloat(t *testing.T) {
+
+	values := []float64{0.1, 1.0 / 3, math.Pi, -2.5e-300, 1.7976931348623157e308, 5e-324, 123456789.123456789}
+
+	for _, v := range values {
+		out, err := Marshal(NewPoint(XY, Coordinate{X: v, Y: -v}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		g, err := Parse(out)
+		if err != nil {
+			t.Fatal(out, ": ", err)
+		}
+		if c := g.(*Point).Coordinate; c.X != v || c.Y != -v {
+			t.Error("Expected ", v, " to round trip got ", c.X, " from ", out)
+		}
+	}
+
+	if out, _ := Marshal(NewPoint(XY, Coordinate{X: 0.1, Y: 0.2})); out != "POINT (0.1 0.2)" {
+		t.Error("Expected POINT (0.1 0.2) got ", out)
+	}
+
+}
+
+func TestMarshalFixedPrecision(t *testing.T) {
+
+	g, err := Parse("LINESTRING (1.23456 -0.0001, 2.75 3, 9.999 1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := MarshalOptions{Fixed: true, Precision: 2}.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "LINESTRING (1.23 0, 2.75 3, 10 1)"; got != want {
+		t.Error("Expected ", want, " got ", got)
+	}
+
+	got, _ = MarshalOptions{Fixed: true}.Marshal(g)
+	if want := "LINESTRING (1 0, 3 3, 10 1)"; got != want {
+		t.Error("Expected ", want, " got ", got)
+	}
+
+}
+
+func TestMarshalPretty(t *testing.T) {
+
+	g, err := Parse("MULTIPOLYGON (((0 0, 1 0, 1 1, 0 0)), ((5 5, 6 5, 6 6, 5 5), (5.2 5.1, 5.8 5.1, 5.8 5.7, 5.2 5.1)))")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := MarshalOptions{Pretty: true}.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"MULTIPOLYGON (",
+		"  (",
+		"    (0 0, 1 0, 1 1, 0 0)",
+		"  ),",
+		"  (",
+		"    (5 5, 6 5, 6 6, 5 5),",
+		"    (5.2 5.1, 5.8 5.1, 5.8 5.7, 5.2 5.1)",
+		"  )",
+		")",
+	}, "\n")
+
+	if got != want {
+		t.Error("Expected\n", want, "\ngot\n", got)
+	}
+
+}
+
+func TestMarshalErrors(t *testing.T) {
+
+	if _, err := Marshal(nil); err == nil {
+		t.Error("Expected an error for a nil geometry")
+	}
+
+	point := NewPoint(XYZ, Coordinate{X: 1, Y: 2, Z: math.NaN()})
+	if _, err := Marshal(point); err == nil {
+		t.Error("Expected an error for NaN")
+	}
+	if s := point.String(); s != "POINT Z (1 2 NaN)" {
+		t.Error("Expected POINT Z (1 2 NaN) got ", s)
+	}
+
+}